@@ -0,0 +1,781 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	semver "github.com/hashicorp/go-version"
+	"github.com/pborman/getopt"
+
+	lib "github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+// command : a tgswitch subcommand. Invoking tgswitch without a subcommand keeps the flag based behaviour
+type command struct {
+	name        string
+	usage       string
+	description string
+	minArgs     int
+	maxArgs     int
+	run         func(ctx context.Context, args []string, opts *commandOptions) error
+	passthrough bool // the arguments after "--" are a command for tgswitch to run, instead of arguments
+	evaluated   bool // stdout is evaluated by the shell, so it only receives shell commands and errors go to stderr
+}
+
+// commandOptions : options shared by the subcommands
+type commandOptions struct {
+	product       *lib.Product // product the commands install and switch, terragrunt unless --product is set
+	binPath       string
+	mirrorURL     string
+	versionURL    string
+	dir           string // directory the version is resolved for
+	configVersion string // version set in the .tgswitch.toml file
+	configFile    string // the .tgswitch.toml file in use
+	stopAt        string // directory the search for version files stops at, the git root when empty
+	terraform     terraformOptions
+	listAll       bool
+	explain       bool   // print the decision trace of the version resolution
+	sort          string // sort order of the installed versions: version (default), installed or size
+	force         bool
+	dryRun        bool
+	upgrade       bool // tgswitch lock resolves the constraints again
+	prune         lib.PrunePolicy
+	autoInstall   bool     // the shims and the shell hook install missing versions
+	execCommand   []string // command run by tgswitch exec, with its arguments
+}
+
+// terraformOptions : settings of terraform or tofu, managed alongside terragrunt with --terraform
+type terraformOptions struct {
+	enabled       bool
+	product       *lib.Product // terraform, or tofu with --terraform-product
+	binPath       string
+	mirrorURL     string
+	configVersion string // terraform_version set in the .tgswitch.toml file
+}
+
+// setDefaults : the bin path and the mirror default to the ones of the product when they are not set
+func (terraform *terraformOptions) setDefaults() {
+	if terraform.binPath == "" {
+		terraform.binPath = lib.ConvertExecutableExt(terraform.product.DefaultBin)
+	}
+	if terraform.mirrorURL == "" {
+		terraform.mirrorURL = terraform.product.DefaultMirror
+	}
+}
+
+var commands = []command{
+	{name: "install", usage: "install [version|constraint|latest]", description: "Download a terragrunt version without switching to it", minArgs: 0, maxArgs: 1, run: runInstall},
+	{name: "use", usage: "use [version|constraint|latest]", description: "Switch to a terragrunt version, downloading it if needed", minArgs: 0, maxArgs: 1, run: runUse},
+	{name: "list", usage: "list", description: "List the installed terragrunt versions. Use --sort to sort by install time or size", minArgs: 0, maxArgs: 0, run: runList},
+	{name: "ls-remote", usage: "ls-remote", description: "List the terragrunt versions available for download. Use -l to include beta and rc", minArgs: 0, maxArgs: 0, run: runLsRemote},
+	{name: "uninstall", usage: "uninstall <version|constraint>", description: "Remove the installed terragrunt versions matching a version or constraint", minArgs: 1, maxArgs: 1, run: runUninstall},
+	{name: "prune", usage: "prune", description: "Remove the installed versions not kept by --keep, --keep-latest-patch or --unused-days", minArgs: 0, maxArgs: 0, run: runPrune},
+	{name: "current", usage: "current", description: "Print the terragrunt version currently in use", minArgs: 0, maxArgs: 0, run: runCurrent},
+	{name: "why", usage: "why [version]", description: "Explain which version source sets the terragrunt version for the directory", minArgs: 0, maxArgs: 1, run: runWhy},
+	{name: "which", usage: "which [version]", description: "Print the path of the terragrunt binary in use, or of an installed version", minArgs: 0, maxArgs: 1, run: runWhich},
+	{name: "exec", usage: "exec [version|constraint|latest] -- <command>", description: "Run a command with a terragrunt version first in PATH, downloading it if needed", minArgs: 0, maxArgs: 1, run: runExec, passthrough: true},
+	{name: "shim", usage: "shim [dir]", description: "Create a terragrunt shim running the version each directory requires, in ~/.tgswitch/shims by default", minArgs: 0, maxArgs: 1, run: runShim},
+	{name: "env", usage: "env [bash|zsh|fish]", description: "Print shell commands pinning terragrunt and TERRAGRUNT_TFPATH for the directory. Ex: eval \"$(tgswitch env)\"", minArgs: 0, maxArgs: 1, run: runEnv, evaluated: true},
+	{name: "lock", usage: "lock", description: "Pin the terragrunt version resolved from the version sources in " + lib.LockFilename + ". Use --upgrade to resolve it again", minArgs: 0, maxArgs: 0, run: runLock},
+	{name: "init", usage: "init <bash|zsh|fish>", description: "Print the shell hook switching terragrunt when the directory changes. Ex: eval \"$(tgswitch init bash)\"", minArgs: 1, maxArgs: 1, run: runInit},
+	{name: "hook", usage: "hook <bash|zsh|fish>", description: "Print the shell commands switching terragrunt for the directory, run by the hook of tgswitch init", minArgs: 1, maxArgs: 1, run: runHook, evaluated: true},
+}
+
+// tfPathEnv : the terraform or tofu binary terragrunt runs, set by tgswitch env
+const tfPathEnv = "TERRAGRUNT_TFPATH"
+
+// findCommand : look up a subcommand by name
+func findCommand(name string) (*command, bool) {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i], true
+		}
+	}
+	return nil, false
+}
+
+// parseCommandArgs : parse the options placed between the arguments of a subcommand and return the arguments,
+// and separately everything after "--"
+func parseCommandArgs(name string, args []string) ([]string, []string) {
+	var cmdArgs []string
+	for len(args) > 0 {
+		getopt.CommandLine.Parse(append([]string{name}, args...))
+		args = getopt.Args()
+		if getopt.CommandLine.State == getopt.DashDash {
+			return cmdArgs, args
+		}
+		if len(args) > 0 {
+			cmdArgs = append(cmdArgs, args[0])
+			args = args[1:]
+		}
+	}
+	return cmdArgs, nil
+}
+
+// execute : run the command, failing with its usage when the number of arguments is wrong
+func (cmd *command) execute(ctx context.Context, args []string, opts *commandOptions) error {
+	if len(args) < cmd.minArgs || len(args) > cmd.maxArgs || (cmd.passthrough && len(opts.execCommand) == 0) {
+		return fmt.Errorf("usage: tgswitch %s", cmd.usage)
+	}
+	return cmd.run(ctx, args, opts)
+}
+
+// resolveVersion : resolve an exact version, a constraint or "latest" to a version of the product.
+// An exact version that is already installed is used without checking the version list
+func resolveVersion(arg string, opts *commandOptions) (string, error) {
+
+	if arg == "latest" {
+		return opts.product.LatestVersion(opts.versionURL)
+	}
+
+	if lib.ValidVersionFormat(arg) {
+		installFileVersionPath, err := opts.product.InstalledVersionPath(arg)
+		if err != nil {
+			return "", err
+		}
+		if lib.CheckFileExist(installFileVersionPath) {
+			return arg, nil
+		}
+
+		return opts.product.ResolveVersion(arg, opts.versionURL)
+	}
+
+	if _, err := semver.NewConstraint(arg); err != nil {
+		opts.product.PrintInvalidVersion()
+		return "", &lib.InvalidVersionError{Version: arg, Product: opts.product.Name}
+	}
+	fmt.Fprintf(lib.Output(), "Reading required version from constraint: %s\n", arg)
+	return opts.product.ResolveVersion(arg, opts.versionURL)
+}
+
+// resolveSources : read the version or constraint from the version sources, the argument has the highest precedence.
+// With --explain the decision trace is printed
+func resolveSources(args []string, opts *commandOptions) (*lib.Resolution, error) {
+	resolution, err := resolveLocked(args, opts)
+	if opts.explain {
+		printTrace(lib.Output(), opts.product.Name, opts.dir, resolution)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if resolution.Version != "" && resolution.Source != lib.CommandLineSource {
+		printRequired(lib.Output(), "version", resolution)
+	}
+	return resolution, nil
+}
+
+// resolveLocked : resolve the version from the version sources, pinned by the lock file of the directory
+func resolveLocked(args []string, opts *commandOptions) (*lib.Resolution, error) {
+	resolution, err := newResolver(args, opts).Resolve(opts.dir)
+	if err != nil {
+		return resolution, err
+	}
+	return resolution, opts.product.ApplyLock(resolution, opts.dir, opts.stopAt)
+}
+
+// terraformCommandOptions : the options of terraform or tofu managed alongside terragrunt with --terraform, for the
+// commands running on one product at a time
+func terraformCommandOptions(opts *commandOptions) *commandOptions {
+	tfOpts := *opts
+	tfOpts.product = opts.terraform.product
+	tfOpts.binPath = opts.terraform.binPath
+	tfOpts.mirrorURL = opts.terraform.mirrorURL
+	tfOpts.versionURL = tfOpts.product.VersionURL(tfOpts.mirrorURL)
+	return &tfOpts
+}
+
+// newResolver : resolve the version from the version sources, with the argument as the source with the highest precedence.
+// The version of terraform and tofu in the .tgswitch.toml file is terraform_version
+func newResolver(args []string, opts *commandOptions) *lib.Resolver {
+	argVersion := ""
+	if len(args) == 1 {
+		argVersion = args[0]
+	}
+	configVersion := opts.configVersion
+	if opts.product != lib.Terragrunt {
+		configVersion = opts.terraform.configVersion
+	}
+	return lib.NewResolver(opts.product.VersionSources(lib.SourceOptions{
+		Version:       argVersion,
+		ConfigVersion: configVersion,
+		ConfigFile:    opts.configFile,
+		StopAt:        opts.stopAt,
+	})...)
+}
+
+// resolveRequiredVersion : resolve the version from the argument or the version sources.
+// Fails when no source sets a version
+func resolveRequiredVersion(args []string, opts *commandOptions) (string, error) {
+	resolution, err := resolveSources(args, opts)
+	if err != nil {
+		return "", err
+	}
+	if resolution.Version == "" {
+		versionFile := opts.product.VersionFiles[len(opts.product.VersionFiles)-1]
+		return "", fmt.Errorf("no %s version found for %s, provide a version or add a %s file", opts.product.Name, opts.dir, versionFile)
+	}
+	return resolveVersion(resolution.Version, opts)
+}
+
+// switchToResolvedVersion : switch to the version from the argument or the version sources.
+// Without any version the versions are presented in a menu
+func switchToResolvedVersion(ctx context.Context, args []string, opts *commandOptions) error {
+	resolution, err := resolveSources(args, opts)
+	if err != nil {
+		return err
+	}
+
+	if resolution.Version == "" {
+		listAll := false //set list all false - only official release will be displayed
+		return installOption(ctx, listAll, opts)
+	}
+
+	tgversion, err := resolveVersion(resolution.Version, opts)
+	if err != nil {
+		return err
+	}
+	return switchToVersions(ctx, tgversion, opts)
+}
+
+// switchToVersions : switch the product to the version, and with --terraform terraform to the version the directory requires.
+// In json mode the result is printed
+func switchToVersions(ctx context.Context, tgversion string, opts *commandOptions) error {
+	if err := opts.product.Install(ctx, tgversion, opts.binPath, opts.mirrorURL); err != nil {
+		return err
+	}
+
+	terraform, err := installTerraform(ctx, opts, true)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput() {
+		installFileVersionPath, err := opts.product.InstalledVersionPath(tgversion)
+		if err != nil {
+			return err
+		}
+		return printJSON(versionResult{Version: tgversion, Path: installFileVersionPath, Terraform: terraform})
+	}
+	return nil
+}
+
+// installTerraform : with --terraform, install the terraform or tofu version required for the directory, and switch
+// to it when link is set. Nothing is installed when no version source sets a version, or when the product managed
+// with --product is not terragrunt
+func installTerraform(ctx context.Context, opts *commandOptions, link bool) (*versionResult, error) {
+	if !opts.terraform.enabled || opts.product != lib.Terragrunt {
+		return nil, nil
+	}
+	return installTerraformVersion(ctx, opts, link)
+}
+
+// installTerraformVersion : install the terraform or tofu version required for the directory, and switch to it
+// when link is set. Returns nil when no version source sets a version
+func installTerraformVersion(ctx context.Context, opts *commandOptions, link bool) (*versionResult, error) {
+	product := opts.terraform.product
+
+	resolution, err := lib.NewResolver(product.VersionSources(lib.SourceOptions{
+		ConfigVersion: opts.terraform.configVersion,
+		ConfigFile:    opts.configFile,
+		StopAt:        opts.stopAt,
+	})...).Resolve(opts.dir)
+	if err == nil {
+		err = product.ApplyLock(resolution, opts.dir, opts.stopAt)
+	}
+	if opts.explain {
+		printTrace(lib.Output(), product.Name, opts.dir, resolution)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resolution.Version == "" {
+		fmt.Fprintf(lib.Output(), "No %s version required for %s, %s is left as is\n", product.Name, opts.dir, product.Name)
+		return nil, nil
+	}
+	printRequired(lib.Output(), product.Name+" version", resolution)
+
+	tfversion := resolution.Version
+	installFileVersionPath, err := product.InstalledVersionPath(tfversion)
+	if err != nil {
+		return nil, err
+	}
+	if !lib.ValidVersionFormat(tfversion) || !lib.CheckFileExist(installFileVersionPath) {
+		if tfversion, err = product.ResolveVersion(tfversion, product.VersionURL(opts.terraform.mirrorURL)); err != nil {
+			return nil, err
+		}
+	}
+
+	if link {
+		err = product.Install(ctx, tfversion, opts.terraform.binPath, opts.terraform.mirrorURL)
+	} else {
+		_, err = product.InstallVersion(ctx, tfversion, opts.terraform.mirrorURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	installFileVersionPath, err = product.InstalledVersionPath(tfversion)
+	if err != nil {
+		return nil, err
+	}
+	return &versionResult{Version: tfversion, Path: installFileVersionPath}, nil
+}
+
+// resolutionLocation : where the resolved version was read from
+func resolutionLocation(resolution *lib.Resolution) string {
+	if resolution.Location != "" {
+		return resolution.Location
+	}
+	return resolution.Source
+}
+
+// printRequired : print the version or constraint read from the version sources, and the version the lock file
+// pins it to
+func printRequired(output io.Writer, label string, resolution *lib.Resolution) {
+	if resolution.Lock == "" {
+		fmt.Fprintf(output, "Reading required %s %s from %s\n", label, resolution.Version, resolutionLocation(resolution))
+		return
+	}
+	fmt.Fprintf(output, "Reading required %s %s from %s\n", label, resolution.Required, resolutionLocation(resolution))
+	fmt.Fprintf(output, "Using %s %s locked in %s\n", label, resolution.Version, resolution.Lock)
+}
+
+// printTrace : print every version source of a product with its outcome, from highest to lowest precedence
+func printTrace(output io.Writer, product string, dir string, resolution *lib.Resolution) {
+	fmt.Fprintf(output, "Version sources of %s for %s, from highest to lowest precedence:\n", product, dir)
+
+	writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	for i, entry := range resolution.Trace {
+		detail := entry.Version
+		if entry.Error != "" {
+			detail = entry.Error
+		}
+		fmt.Fprintf(writer, "  %d.\t%s\t%s\t%s\t%s\n", i+1, entry.Source, entry.Status, detail, entry.Location)
+	}
+	writer.Flush()
+
+	if resolution.Version == "" {
+		fmt.Fprintf(output, "No version source sets a %s version\n", product)
+	} else if resolution.Lock == "" {
+		fmt.Fprintf(output, "Selected %s from %s\n", resolution.Version, resolutionLocation(resolution))
+	} else {
+		fmt.Fprintf(output, "Selected %s from %s, locked to %s in %s\n", resolution.Required, resolutionLocation(resolution), resolution.Version, resolution.Lock)
+	}
+}
+
+// runWhy : explain which version source sets the version
+func runWhy(ctx context.Context, args []string, opts *commandOptions) error {
+	resolution, err := resolveLocked(args, opts)
+	if resolution == nil {
+		return err
+	}
+
+	if !jsonOutput() {
+		printTrace(os.Stdout, opts.product.Name, opts.dir, resolution)
+		return err
+	}
+
+	/* one document, carrying the trace up to the error */
+	result := whyResult{Resolution: resolution}
+	if err != nil {
+		detail := newErrorDetail(err)
+		result.Error = &detail
+	}
+	if errJSON := printJSON(result); errJSON != nil {
+		return errJSON
+	}
+	if err != nil {
+		return &reportedError{err: err}
+	}
+	return nil
+}
+
+// runInstall : download a version without switching to it
+func runInstall(ctx context.Context, args []string, opts *commandOptions) error {
+	tgversion, err := resolveRequiredVersion(args, opts)
+	if err != nil {
+		return err
+	}
+
+	installFileVersionPath, err := opts.product.InstallVersion(ctx, tgversion, opts.mirrorURL)
+	if err != nil {
+		return err
+	}
+	terraform, err := installTerraform(ctx, opts, false)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput() {
+		return printJSON(versionResult{Version: tgversion, Path: installFileVersionPath, Terraform: terraform})
+	}
+	fmt.Printf("Installed %s version %q at %s\n", opts.product.Name, tgversion, installFileVersionPath)
+	if terraform != nil {
+		fmt.Printf("Installed %s version %q at %s\n", opts.terraform.product.Name, terraform.Version, terraform.Path)
+	}
+	return nil
+}
+
+// runUse : switch to a version, downloading it if needed
+func runUse(ctx context.Context, args []string, opts *commandOptions) error {
+	tgversion, err := resolveRequiredVersion(args, opts)
+	if err != nil {
+		return err
+	}
+
+	return switchToVersions(ctx, tgversion, opts)
+}
+
+// runList : print the installed versions with their size and install time, marking the version in use
+// and the recent versions. Stray files found in the install location are reported after the versions
+func runList(ctx context.Context, args []string, opts *commandOptions) error {
+	installed, strays, err := opts.product.ListInstalledVersions(opts.binPath)
+	if err != nil {
+		return err
+	}
+
+	switch opts.sort {
+	case "installed":
+		sort.SliceStable(installed, func(i, j int) bool { return installed[i].InstalledAt.After(installed[j].InstalledAt) })
+	case "size":
+		sort.SliceStable(installed, func(i, j int) bool { return installed[i].Size > installed[j].Size })
+	}
+
+	if jsonOutput() {
+		if strays == nil {
+			strays = []lib.StrayFile{}
+		}
+		return printJSON(installedVersionsResult{Versions: installed, StrayFiles: strays})
+	}
+
+	if len(installed) == 0 {
+		fmt.Printf("No %s versions installed\n", opts.product.Name)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, version := range installed {
+		var markers []string
+		if version.Current {
+			markers = append(markers, "*current")
+		}
+		if version.Recent {
+			markers = append(markers, "*recent")
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", version.Version, formatSize(version.Size), version.InstalledAt.Format("2006-01-02 15:04"), strings.Join(markers, " "))
+	}
+	writer.Flush()
+
+	if len(strays) > 0 {
+		fmt.Println("\nStray files found in the install location:")
+		for _, stray := range strays {
+			fmt.Printf("  %s (%s)\n", stray.Path, stray.Reason)
+		}
+	}
+	return nil
+}
+
+// formatSize : format a file size for humans
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// runLsRemote : print the versions available for download, marking the installed versions
+func runLsRemote(ctx context.Context, args []string, opts *commandOptions) error {
+	releases, err := opts.product.ListReleases(opts.versionURL, opts.listAll)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput() {
+		return printRemoteVersions(releases, opts.product)
+	}
+
+	installed, err := opts.product.InstalledVersions()
+	if err != nil {
+		return err
+	}
+
+	for _, release := range releases {
+		if lib.VersionExist(release.Version, installed) {
+			fmt.Printf("%s *installed\n", release.Version)
+		} else {
+			fmt.Println(release.Version)
+		}
+	}
+	return nil
+}
+
+// printRemoteVersions : print the versions available for download as JSON, marking the installed versions
+func printRemoteVersions(releases []lib.Release, product *lib.Product) error {
+	installed, err := product.InstalledVersions()
+	if err != nil {
+		return err
+	}
+
+	result := remoteVersionsResult{Versions: []remoteVersion{}}
+	for _, release := range releases {
+		version := remoteVersion{Version: release.Version, Installed: lib.VersionExist(release.Version, installed)}
+		if !release.Published.IsZero() {
+			version.Published = release.Published.UTC().Format(time.RFC3339)
+		}
+		result.Versions = append(result.Versions, version)
+	}
+	return printJSON(result)
+}
+
+// runUninstall : remove the installed versions matching a version or constraint
+func runUninstall(ctx context.Context, args []string, opts *commandOptions) error {
+	removed, err := opts.product.UninstallVersions(ctx, args[0], opts.binPath, opts.force, opts.dryRun)
+	if err != nil {
+		return err
+	}
+	return printRemoved(removed, opts)
+}
+
+// runPrune : remove the installed versions that are not kept by the retention policy
+func runPrune(ctx context.Context, args []string, opts *commandOptions) error {
+	policy := opts.prune
+	policy.Force = opts.force
+	policy.DryRun = opts.dryRun
+
+	removed, err := opts.product.PruneVersions(ctx, opts.binPath, policy)
+	if err != nil {
+		return err
+	}
+	return printRemoved(removed, opts)
+}
+
+// printRemoved : summarize the removed versions, or list the versions a dry run would remove
+func printRemoved(removed []lib.InstalledVersion, opts *commandOptions) error {
+	dryRun := opts.dryRun
+	if jsonOutput() {
+		if removed == nil {
+			removed = []lib.InstalledVersion{}
+		}
+		return printJSON(removedVersionsResult{Removed: removed, DryRun: dryRun})
+	}
+
+	var size int64
+	for _, version := range removed {
+		size += version.Size
+		if dryRun {
+			fmt.Printf("Would remove %s version %q (%s, last used %s)\n", opts.product.Name, version.Version, formatSize(version.Size), version.LastUsed.Format("2006-01-02"))
+		}
+	}
+
+	switch {
+	case len(removed) == 0:
+		fmt.Printf("No %s versions to remove\n", opts.product.Name)
+	case dryRun:
+		fmt.Printf("Dry run: %d versions would be removed, freeing %s\n", len(removed), formatSize(size))
+	default:
+		fmt.Printf("Removed %d versions, freed %s\n", len(removed), formatSize(size))
+	}
+	return nil
+}
+
+// runCurrent : print the version in use
+func runCurrent(ctx context.Context, args []string, opts *commandOptions) error {
+	current, err := currentVersion(opts)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput() {
+		installFileVersionPath, err := opts.product.InstalledVersionPath(current)
+		if err != nil {
+			return err
+		}
+		return printJSON(versionResult{Version: current, Path: installFileVersionPath})
+	}
+	fmt.Println(current)
+	return nil
+}
+
+// runWhich : print the path of the binary in use, or of the provided version
+func runWhich(ctx context.Context, args []string, opts *commandOptions) error {
+	var tgversion string
+	if len(args) == 1 {
+		tgversion = args[0]
+		if !lib.ValidVersionFormat(tgversion) {
+			opts.product.PrintInvalidVersion()
+			return &lib.InvalidVersionError{Version: tgversion, Product: opts.product.Name}
+		}
+	} else {
+		current, err := currentVersion(opts)
+		if err != nil {
+			return err
+		}
+		tgversion = current
+	}
+
+	installFileVersionPath, err := opts.product.InstalledVersionPath(tgversion)
+	if err != nil {
+		return err
+	}
+	if !lib.CheckFileExist(installFileVersionPath) {
+		return &lib.NotInstalledError{Version: tgversion, Product: opts.product.Name}
+	}
+
+	if jsonOutput() {
+		return printJSON(versionResult{Version: tgversion, Path: installFileVersionPath})
+	}
+	fmt.Println(installFileVersionPath)
+	return nil
+}
+
+// runExec : run a command with the version of the product, and with --terraform the terraform version the directory
+// requires, first in PATH. The bin links are left as is. The process is replaced by the command, so it receives
+// the signals and its exit code is the one of tgswitch
+func runExec(ctx context.Context, args []string, opts *commandOptions) error {
+	lib.SetOutput(os.Stderr) //stdout belongs to the command
+
+	tgversion, err := resolveRequiredVersion(args, opts)
+	if err != nil {
+		return err
+	}
+	if _, err := opts.product.InstallVersion(ctx, tgversion, opts.mirrorURL); err != nil {
+		return err
+	}
+	linkDir, err := opts.product.LinkDir(tgversion)
+	if err != nil {
+		return err
+	}
+	path := []string{linkDir}
+
+	terraform, err := installTerraform(ctx, opts, false)
+	if err != nil {
+		return err
+	}
+	if terraform != nil {
+		tfLinkDir, err := opts.terraform.product.LinkDir(terraform.Version)
+		if err != nil {
+			return err
+		}
+		path = append(path, tfLinkDir)
+	}
+
+	/* the command is looked up in the new PATH, so "terragrunt" is the version in the link directory */
+	path = append(path, os.Getenv("PATH"))
+	if err := os.Setenv("PATH", strings.Join(path, string(os.PathListSeparator))); err != nil {
+		return err
+	}
+	command, err := exec.LookPath(opts.execCommand[0])
+	if err != nil {
+		return err
+	}
+
+	code, err := lib.ExecBinary(command, opts.execCommand[1:], os.Environ())
+	if err != nil {
+		return err
+	}
+	os.Exit(code)
+	return nil
+}
+
+// runEnv : print the shell commands putting the versions the directory requires first in PATH, and pointing
+// TERRAGRUNT_TFPATH to the terraform version, installing them if needed. The bin links are left as is, so every
+// shell can pin its own versions. The link directories of a previous tgswitch env or hook are replaced, and the
+// variables of versions that are not required are removed
+func runEnv(ctx context.Context, args []string, opts *commandOptions) error {
+	shell := lib.DetectShell(os.Getenv("SHELL"))
+	if len(args) == 1 {
+		shell = args[0]
+	}
+	if err := lib.ValidShell(shell); err != nil {
+		return err
+	}
+	if opts.product != lib.Terragrunt {
+		return fmt.Errorf("tgswitch env pins terragrunt, use --terraform-product to choose between terraform and tofu")
+	}
+	lib.SetOutput(os.Stderr) //stdout is evaluated by the shell
+
+	result := versionResult{}
+	resolution, err := resolveSources(nil, opts)
+	if err != nil {
+		return err
+	}
+	var linkDirs []string
+	if resolution.Version != "" {
+		if result.Version, err = resolveVersion(resolution.Version, opts); err != nil {
+			return err
+		}
+		if result.Path, err = opts.product.InstallVersion(ctx, result.Version, opts.mirrorURL); err != nil {
+			return err
+		}
+		linkDir, err := opts.product.LinkDir(result.Version)
+		if err != nil {
+			return err
+		}
+		linkDirs = append(linkDirs, linkDir)
+	}
+	if result.Terraform, err = installTerraformVersion(ctx, opts, false); err != nil {
+		return err
+	}
+	if result.Terraform != nil {
+		tfLinkDir, err := opts.terraform.product.LinkDir(result.Terraform.Version)
+		if err != nil {
+			return err
+		}
+		linkDirs = append(linkDirs, tfLinkDir)
+	}
+
+	if jsonOutput() {
+		return printJSON(result)
+	}
+
+	/* scripts and make started from the shell find the versions in PATH as well */
+	envPath := strings.Join(linkDirs, string(os.PathListSeparator))
+	path, _ := hookPathUpdate(os.Getenv("PATH"), os.Getenv(hookPathEnv), envPath)
+	fmt.Println(exportPath(shell, path))
+	if envPath != "" {
+		fmt.Println(lib.ShellExport(shell, hookPathEnv, envPath))
+	} else {
+		fmt.Println(lib.ShellUnset(shell, hookPathEnv))
+	}
+	if result.Terraform != nil {
+		fmt.Println(lib.ShellExport(shell, tfPathEnv, result.Terraform.Path))
+	} else {
+		fmt.Println(lib.ShellUnset(shell, tfPathEnv))
+	}
+	return nil
+}
+
+// currentVersion : get the version in use, failing when the bin path does not point to an installed version
+func currentVersion(opts *commandOptions) (string, error) {
+	current, err := opts.product.CurrentVersion(opts.binPath)
+	if err != nil {
+		return "", err
+	}
+	if current == "" {
+		return "", fmt.Errorf("%s does not point to a %s version installed by tgswitch", opts.binPath, opts.product.Name)
+	}
+	return current, nil
+}
+
+// commandsUsage : describe the subcommands for the help message
+func commandsUsage() string {
+	var usage strings.Builder
+	usage.WriteString("Commands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(&usage, "  %-38s %s\n", cmd.usage, cmd.description)
+	}
+	return usage.String()
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pborman/getopt"
+
+	lib "github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+/* options parsed between the arguments of a subcommand, registered like main does */
+var (
+	testBinPath = getopt.StringLong("bin", 'b', "", "Custom binary path")
+	testListAll = getopt.BoolLong("list-all", 'l', "List all versions")
+)
+
+// TestParseCommandArgs : options may be mixed with the arguments of a subcommand, everything after "--" is kept
+func TestParseCommandArgs(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		args        []string
+		cmdArgs     []string
+		passthrough []string
+		binPath     string
+		listAll     bool
+	}{
+		{name: "No arguments", args: nil},
+		{name: "Argument only", args: []string{"0.38.0"}, cmdArgs: []string{"0.38.0"}},
+		{name: "Option after the argument", args: []string{"0.38.0", "-b", "/tmp/terragrunt"}, cmdArgs: []string{"0.38.0"}, binPath: "/tmp/terragrunt"},
+		{name: "Options around the argument", args: []string{"-l", "0.38.0", "--bin=/tmp/terragrunt"}, cmdArgs: []string{"0.38.0"}, binPath: "/tmp/terragrunt", listAll: true},
+		{name: "Command after --", args: []string{"0.38.0", "--", "terragrunt", "plan", "-b", "x"}, cmdArgs: []string{"0.38.0"}, passthrough: []string{"terragrunt", "plan", "-b", "x"}},
+		{name: "Command without argument", args: []string{"-l", "--", "terragrunt", "--version"}, passthrough: []string{"terragrunt", "--version"}, listAll: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name,
+			func(t *testing.T) {
+				*testBinPath, *testListAll = "", false
+
+				cmdArgs, passthrough := parseCommandArgs("exec", test.args)
+				if !reflect.DeepEqual(cmdArgs, test.cmdArgs) {
+					t.Errorf("Expected arguments %q, got %q [unexpected]", test.cmdArgs, cmdArgs)
+				}
+				if !reflect.DeepEqual(passthrough, test.passthrough) {
+					t.Errorf("Expected command %q, got %q [unexpected]", test.passthrough, passthrough)
+				}
+				if *testBinPath != test.binPath || *testListAll != test.listAll {
+					t.Errorf("Expected options bin=%q list-all=%v, got bin=%q list-all=%v [unexpected]", test.binPath, test.listAll, *testBinPath, *testListAll)
+				}
+			},
+		)
+	}
+}
+
+// TestFindCommand : subcommands are found by name, a version is never taken for a subcommand so tgswitch 0.38.0 keeps working
+func TestFindCommand(t *testing.T) {
+
+	tests := []struct {
+		arg       string
+		isCommand bool
+	}{
+		{arg: "use", isCommand: true},
+		{arg: "ls-remote", isCommand: true},
+		{arg: "exec", isCommand: true},
+		{arg: "0.38.0", isCommand: false},
+		{arg: "0.38", isCommand: false},
+		{arg: "0.38.0-rc1", isCommand: false},
+		{arg: ">= 0.38", isCommand: false},
+		{arg: "latest", isCommand: false},
+	}
+
+	for _, test := range tests {
+		cmd, isCommand := findCommand(test.arg)
+		if isCommand != test.isCommand {
+			t.Errorf("findCommand(%q) = %v, expected %v [unexpected]", test.arg, isCommand, test.isCommand)
+		}
+		if isCommand && cmd.name != test.arg {
+			t.Errorf("findCommand(%q) found %q [unexpected]", test.arg, cmd.name)
+		}
+	}
+
+	for _, cmd := range commands {
+		if lib.ValidVersionFormat(cmd.name) || lib.ValidMinorVersionFormat(cmd.name) {
+			t.Errorf("Command %q would shadow a version [unexpected]", cmd.name)
+		}
+	}
+}
+
+// TestWhyJSONError : with --output json, tgswitch why prints a single document carrying the trace and the error
+func TestWhyJSONError(t *testing.T) {
+
+	t.Setenv("HOME", t.TempDir())
+	project := t.TempDir()
+	dir := filepath.Join(project, "live")
+	for _, path := range []string{filepath.Join(project, ".git"), dir} {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "terragrunt.hcl"), []byte("terragrunt_version_constraint = \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	outputFormat = outputJSON
+	defer func() { outputFormat = outputText }()
+	opts := &commandOptions{product: lib.Terragrunt, dir: dir}
+
+	code := 0
+	stdout, _ := captureOutput(t, func() {
+		if err := runWhy(context.Background(), nil, opts); err != nil {
+			code = printError(err)
+		}
+	})
+
+	decoder := json.NewDecoder(strings.NewReader(stdout))
+	var result struct {
+		Trace []lib.TraceEntry `json:"trace"`
+		Error *errorDetail     `json:"error"`
+	}
+	if err := decoder.Decode(&result); err != nil {
+		t.Fatalf("Expected a JSON document, got %q %v [unexpected]", stdout, err)
+	}
+	if decoder.More() {
+		t.Errorf("Expected a single JSON document, got %q [unexpected]", stdout)
+	}
+	if result.Error == nil || result.Error.Code != code || code == 0 || len(result.Trace) == 0 {
+		t.Errorf("Expected the trace and the error with exit code %d, got %q [unexpected]", code, stdout)
+	}
+}
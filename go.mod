@@ -10,6 +10,8 @@ require (
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/pborman/getopt v0.0.0-20170112200414-7148bc3a4c30
 	github.com/spf13/viper v1.4.0
+	github.com/zclconf/go-cty v1.8.0
+	golang.org/x/crypto v0.0.0-20220525230936-793ad666bf5e
 	golang.org/x/sys v0.0.0-20210630005230-0f9fa26af87c
 )
 
@@ -36,7 +38,6 @@ require (
 	github.com/spf13/cast v1.3.0 // indirect
 	github.com/spf13/jwalterweatherman v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.3 // indirect
-	github.com/zclconf/go-cty v1.8.0 // indirect
-	golang.org/x/text v0.3.5 // indirect
+	golang.org/x/text v0.3.6 // indirect
 	gopkg.in/yaml.v2 v2.2.2 // indirect
 )
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	lib "github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+// hookPathEnv : the link directories the shell hook put in front of PATH, separated like PATH
+const hookPathEnv = "TGSWITCH_PATH"
+
+// hookScripts : the hooks printed by tgswitch init, %[1]s is the quoted tgswitch executable.
+// The hook only runs tgswitch when the working directory changed
+var hookScripts = map[string]string{
+	lib.ShellBash: `_tgswitch_hook() {
+  local status=$?
+  if [ "$PWD" != "${_TGSWITCH_PWD:-}" ]; then
+    _TGSWITCH_PWD=$PWD
+    eval "$(%[1]s hook bash)"
+  fi
+  return $status
+}
+if [[ ";${PROMPT_COMMAND:-};" != *";_tgswitch_hook;"* ]]; then
+  PROMPT_COMMAND="_tgswitch_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+`,
+	lib.ShellZsh: `_tgswitch_hook() {
+  eval "$(%[1]s hook zsh)"
+}
+autoload -Uz add-zsh-hook
+add-zsh-hook chpwd _tgswitch_hook
+_tgswitch_hook
+`,
+	lib.ShellFish: `function _tgswitch_hook --on-variable PWD
+  %[1]s hook fish | source
+end
+_tgswitch_hook
+`,
+}
+
+// runInit : print the hook to evaluate in the shell startup file. Ex: eval "$(tgswitch init bash)"
+func runInit(ctx context.Context, args []string, opts *commandOptions) error {
+	shell := args[0]
+	if err := lib.ValidShell(shell); err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err == nil {
+		executable, err = filepath.EvalSymlinks(executable)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to find the tgswitch executable: %w", err)
+	}
+
+	fmt.Print(fmt.Sprintf(hookScripts[shell], lib.ShellQuote(shell, executable)))
+	return nil
+}
+
+// runHook : called by the shell hook when the working directory changed. Prints the commands putting the link
+// directories of the versions the directory requires in front of PATH, and nothing when they are already there.
+// Only switches are reported, on stderr
+func runHook(ctx context.Context, args []string, opts *commandOptions) error {
+	shell := args[0]
+	if err := lib.ValidShell(shell); err != nil {
+		return err
+	}
+	if opts.product != lib.Terragrunt {
+		return fmt.Errorf("tgswitch hook switches terragrunt, use --terraform-product to choose between terraform and tofu")
+	}
+	if err := readAutoInstallEnv(opts); err != nil {
+		return err
+	}
+	lib.SetOutput(io.Discard)
+
+	products := []*commandOptions{opts}
+	if opts.terraform.enabled {
+		products = append(products, terraformCommandOptions(opts))
+	}
+
+	var linkDirs, switched []string
+	for _, productOpts := range products {
+		version, source, err := hookVersion(productOpts)
+		if err != nil {
+			return err
+		}
+		if version == "" {
+			continue
+		}
+		linkDir, err := productOpts.product.LinkDir(version)
+		if err != nil {
+			return err
+		}
+		linkDirs = append(linkDirs, linkDir)
+		switched = append(switched, fmt.Sprintf("%s %s (%s)", productOpts.product.Name, version, source))
+	}
+
+	hookPath := strings.Join(linkDirs, string(os.PathListSeparator))
+	path, changed := hookPathUpdate(os.Getenv("PATH"), os.Getenv(hookPathEnv), hookPath)
+	if !changed {
+		return nil
+	}
+	if hookPath == "" {
+		fmt.Println(exportPath(shell, path))
+		fmt.Println(lib.ShellUnset(shell, hookPathEnv))
+		fmt.Fprintln(os.Stderr, "tgswitch: no version required, using the terragrunt found in PATH")
+		return nil
+	}
+	fmt.Println(exportPath(shell, path))
+	fmt.Println(lib.ShellExport(shell, hookPathEnv, hookPath))
+	fmt.Fprintf(os.Stderr, "tgswitch: using %s\n", strings.Join(switched, ", "))
+	return nil
+}
+
+// hookVersion : the exact version of the product the directory requires and where it was read from, cached per
+// directory. Empty when no version source sets a version
+func hookVersion(opts *commandOptions) (string, string, error) {
+	product := opts.product
+	cache, err := product.LoadResolutionCache()
+	if err != nil {
+		return "", "", err
+	}
+
+	/* the settings the resolution depends on besides the version files */
+	key := strings.Join([]string{os.Getenv(product.VersionEnv), opts.configVersion, opts.terraform.configVersion, opts.stopAt}, "\x00")
+	if version, source, found := cache.Lookup(opts.dir, key); found {
+		if version == "" {
+			return "", source, nil
+		}
+		/* a version uninstalled since is resolved again */
+		if installFileVersionPath, err := product.InstalledVersionPath(version); err == nil && lib.CheckFileExist(installFileVersionPath) {
+			return version, source, nil
+		}
+	}
+
+	resolution, err := resolveLocked(nil, opts)
+	if err != nil {
+		return "", "", err
+	}
+	version := ""
+	if resolution.Version != "" {
+		if version, _, err = installedBinary(resolution.Version, opts); err != nil {
+			return "", "", err
+		}
+	}
+
+	cache.Store(opts.dir, key, resolutionLocation(resolution), resolution, version)
+	cache.Save() //without the cache the next change of directory resolves again
+	return version, resolutionLocation(resolution), nil
+}
+
+// exportPath : the command exporting PATH, fish sets PATH as a list
+func exportPath(shell string, path string) string {
+	if shell != lib.ShellFish {
+		return lib.ShellExport(shell, "PATH", path)
+	}
+	var entries []string
+	for _, entry := range filepath.SplitList(path) {
+		entries = append(entries, lib.ShellQuote(shell, entry))
+	}
+	return fmt.Sprintf("set -gx PATH %s;", strings.Join(entries, " "))
+}
+
+// hookPathUpdate : PATH with the link directories the hook put in front of it before, active, replaced by hookPath.
+// changed is false when hookPath is already the active one
+func hookPathUpdate(path string, active string, hookPath string) (string, bool) {
+	if hookPath == active {
+		return path, false
+	}
+
+	/* the link directories of the previous directory are replaced */
+	path = removePathEntries(path, filepath.SplitList(active))
+	if hookPath == "" {
+		return path, true
+	}
+	return hookPath + string(os.PathListSeparator) + path, true
+}
+
+// removePathEntries : remove the entries from a PATH style list
+func removePathEntries(path string, entries []string) string {
+	var kept []string
+	for _, entry := range filepath.SplitList(path) {
+		if !lib.VersionExist(entry, entries) {
+			kept = append(kept, entry)
+		}
+	}
+	return strings.Join(kept, string(os.PathListSeparator))
+}
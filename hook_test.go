@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	lib "github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+// joinPath : a PATH style list of the entries
+func joinPath(entries ...string) string {
+	return strings.Join(entries, string(os.PathListSeparator))
+}
+
+// TestRemovePathEntries : only the listed entries are removed from PATH, in place
+func TestRemovePathEntries(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		path    string
+		entries []string
+		want    string
+	}{
+		{name: "Nothing to remove", path: joinPath("/usr/bin", "/bin"), entries: nil, want: joinPath("/usr/bin", "/bin")},
+		{name: "Entry in front", path: joinPath("/home/user/.terragrunt.versions/0.38.0", "/usr/bin", "/bin"), entries: []string{"/home/user/.terragrunt.versions/0.38.0"}, want: joinPath("/usr/bin", "/bin")},
+		{name: "Several entries anywhere", path: joinPath("/a", "/usr/bin", "/b", "/bin"), entries: []string{"/a", "/b"}, want: joinPath("/usr/bin", "/bin")},
+		{name: "Prefix of an entry is kept", path: joinPath("/a/b", "/a"), entries: []string{"/a"}, want: "/a/b"},
+		{name: "Missing entry", path: joinPath("/usr/bin", "/bin"), entries: []string{"/a"}, want: joinPath("/usr/bin", "/bin")},
+	}
+
+	for _, test := range tests {
+		if got := removePathEntries(test.path, test.entries); got != test.want {
+			t.Errorf("%s: expected %q, got %q [unexpected]", test.name, test.want, got)
+		}
+	}
+}
+
+// TestHookPathUpdate : the link directories of the previous directory, in TGSWITCH_PATH, are replaced in PATH
+func TestHookPathUpdate(t *testing.T) {
+
+	tg38 := "/home/user/.terragrunt.versions/0.38.0"
+	tg40 := "/home/user/.terragrunt.versions/0.40.0"
+	tf15 := "/home/user/.terraform.versions/1.5.7"
+	path := joinPath("/usr/bin", "/bin")
+
+	tests := []struct {
+		name     string
+		path     string
+		active   string
+		hookPath string
+		want     string
+		changed  bool
+	}{
+		{name: "No version before nor now", path: path, want: path},
+		{name: "First version", path: path, hookPath: tg38, want: joinPath(tg38, "/usr/bin", "/bin"), changed: true},
+		{name: "Same version", path: joinPath(tg38, "/usr/bin", "/bin"), active: tg38, hookPath: tg38, want: joinPath(tg38, "/usr/bin", "/bin")},
+		{name: "Other version", path: joinPath(tg38, "/usr/bin", "/bin"), active: tg38, hookPath: tg40, want: joinPath(tg40, "/usr/bin", "/bin"), changed: true},
+		{name: "No version anymore", path: joinPath(tg38, "/usr/bin", "/bin"), active: tg38, want: path, changed: true},
+		{name: "With terraform", path: joinPath(tg38, "/usr/bin", "/bin"), active: tg38, hookPath: joinPath(tg38, tf15), want: joinPath(tg38, tf15, "/usr/bin", "/bin"), changed: true},
+		{name: "Entry moved by the user", path: joinPath("/usr/bin", tg38, "/bin"), active: tg38, hookPath: tg40, want: joinPath(tg40, "/usr/bin", "/bin"), changed: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name,
+			func(t *testing.T) {
+				got, changed := hookPathUpdate(test.path, test.active, test.hookPath)
+				if got != test.want || changed != test.changed {
+					t.Errorf("Expected %q (changed %v), got %q (changed %v) [unexpected]", test.want, test.changed, got, changed)
+				}
+			},
+		)
+	}
+}
+
+// captureOutput : run f and return what it printed on stdout and stderr
+func captureOutput(t *testing.T, f func()) (string, string) {
+	stdout, stderr := os.Stdout, os.Stderr
+	defer func() { os.Stdout, os.Stderr = stdout, stderr }()
+
+	var captured [2]string
+	var readers [2]*os.File
+	var writers [2]*os.File
+	for i := range readers {
+		reader, writer, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		readers[i], writers[i] = reader, writer
+	}
+	os.Stdout, os.Stderr = writers[0], writers[1]
+
+	done := make(chan struct{})
+	for i := range readers {
+		go func(i int) {
+			content, _ := io.ReadAll(readers[i])
+			captured[i] = string(content)
+			done <- struct{}{}
+		}(i)
+	}
+	f()
+	writers[0].Close()
+	writers[1].Close()
+	<-done
+	<-done
+	return captured[0], captured[1]
+}
+
+// TestEvaluatedCommandErrors : the errors of the commands evaluated by the shell are only printed on stderr, even
+// when they contain a path the shell would run
+func TestEvaluatedCommandErrors(t *testing.T) {
+
+	t.Setenv("HOME", t.TempDir())
+	project := t.TempDir()
+	dir := filepath.Join(project, "$(echo INJECTED >&2)")
+	for _, path := range []string{filepath.Join(project, ".git"), dir} {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "terragrunt.hcl"), []byte("terragrunt_version_constraint = \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() { shellOutput = false }()
+	defer lib.SetOutput(os.Stdout)
+
+	for _, name := range []string{"hook", "env"} {
+		t.Run(name,
+			func(t *testing.T) {
+				cmd, _ := findCommand(name)
+				opts := &commandOptions{product: lib.Terragrunt, dir: dir, terraform: terraformOptions{product: lib.Terraform}}
+
+				stdout, stderr := captureOutput(t, func() {
+					setShellOutput()
+					err := cmd.execute(context.Background(), []string{lib.ShellBash}, opts)
+					if err == nil {
+						t.Error("Expected the broken terragrunt.hcl to fail [unexpected]")
+						return
+					}
+					printError(err)
+				})
+				if stdout != "" {
+					t.Errorf("Expected nothing on stdout, got %q [unexpected]", stdout)
+				}
+				if !strings.Contains(stderr, "[Error]") {
+					t.Errorf("Expected the error on stderr, got %q [unexpected]", stderr)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,141 @@
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	checksumFile = "SHA256SUMS" //verified digests of installed binaries, kept in the install location
+)
+
+// GetChecksums : download a checksum file and return a map of file name to sha256 digest
+func GetChecksums(checksumURL string) (map[string]string, error) {
+	data, err := DownloadBytes(checksumURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseChecksums(bytes.NewReader(data))
+}
+
+// ParseChecksums : parse checksum lines in the `sha256sum` format ("<digest>  <file name>")
+func ParseChecksums(reader io.Reader) (map[string]string, error) {
+	checksums := map[string]string{}
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid checksum line: %q", line)
+		}
+		//sha256sum marks binary mode with a leading '*'
+		checksums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return checksums, nil
+}
+
+// FileChecksum : return the hex encoded sha256 digest of a file
+func FileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// VerifyChecksum : check that the sha256 digest of a file matches the expected digest
+func VerifyChecksum(path string, expected string) error {
+	actual, err := FileChecksum(path)
+	if err != nil {
+		return fmt.Errorf("unable to compute checksum of %s: %w", path, err)
+	}
+
+	if !strings.EqualFold(actual, expected) {
+		return &ChecksumError{File: filepath.Base(path), Expected: expected, Actual: actual}
+	}
+
+	return nil
+}
+
+// ReadChecksums : read the digests recorded in a checksum file. A missing file has no digests.
+func ReadChecksums(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseChecksums(file)
+}
+
+// RecordChecksum : record the verified digest of an installed file in the checksum file of the install location
+func RecordChecksum(installLocation string, fileName string, digest string) error {
+	path := filepath.Join(installLocation, checksumFile)
+
+	checksums, err := ReadChecksums(path)
+	if err != nil {
+		return err
+	}
+	checksums[fileName] = digest
+
+	return writeChecksums(path, checksums)
+}
+
+// RemoveChecksum : remove the recorded digest of an uninstalled file from the checksum file of the install location
+func RemoveChecksum(installLocation string, fileName string) error {
+	path := filepath.Join(installLocation, checksumFile)
+
+	checksums, err := ReadChecksums(path)
+	if err != nil {
+		return err
+	}
+	if _, recorded := checksums[fileName]; !recorded {
+		return nil
+	}
+	delete(checksums, fileName)
+
+	return writeChecksums(path, checksums)
+}
+
+// writeChecksums : write the digests sorted by file name, in the format of the release checksum file
+func writeChecksums(path string, checksums map[string]string) error {
+	names := make([]string, 0, len(checksums))
+	for name := range checksums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		lines = append(lines, checksums[name]+"  "+name)
+	}
+
+	return WriteLines(lines, path)
+}
@@ -0,0 +1,97 @@
+package lib_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+const (
+	testBinaryContent = "#!/bin/sh\necho terragrunt\n"
+	testBinaryDigest  = "913a0e1f8a6b76f9c058e477b6cbe18732494494cceb6abaac804a56a090f9ed"
+)
+
+// TestGetChecksums : serve a SHA256SUMS file and check the digests are parsed
+func TestGetChecksums(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v0.26.7/SHA256SUMS" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, "%s  terragrunt_linux_amd64\n", testBinaryDigest)
+		fmt.Fprintf(w, "%s *terragrunt_darwin_amd64\n", strings.Repeat("a", 64))
+	}))
+	defer server.Close()
+
+	checksums, err := lib.GetChecksums(server.URL + "/v0.26.7/SHA256SUMS")
+	if err != nil {
+		t.Fatalf("Unable to get checksums %v [unexpected]", err)
+	}
+
+	if checksums["terragrunt_linux_amd64"] != testBinaryDigest {
+		t.Errorf("Unexpected digest for terragrunt_linux_amd64: %q [unexpected]", checksums["terragrunt_linux_amd64"])
+	}
+
+	if checksums["terragrunt_darwin_amd64"] != strings.Repeat("a", 64) {
+		t.Errorf("Unexpected digest for terragrunt_darwin_amd64: %q [unexpected]", checksums["terragrunt_darwin_amd64"])
+	}
+
+	_, err = lib.GetChecksums(server.URL + "/v0.0.0/SHA256SUMS")
+	if err == nil {
+		t.Error("Missing checksum file should return an error [unexpected]")
+	} else {
+		t.Logf("Missing checksum file returned error %v [expected]", err)
+	}
+}
+
+// TestVerifyChecksum : check a file against a matching and a mismatching digest
+func TestVerifyChecksum(t *testing.T) {
+
+	binary := filepath.Join(t.TempDir(), "terragrunt_linux_amd64")
+	if err := os.WriteFile(binary, []byte(testBinaryContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.VerifyChecksum(binary, testBinaryDigest); err != nil {
+		t.Errorf("Checksum should match %v [unexpected]", err)
+	} else {
+		t.Log("Checksum matches [expected]")
+	}
+
+	if err := lib.VerifyChecksum(binary, strings.Repeat("0", 64)); err == nil {
+		t.Error("Checksum should not match [unexpected]")
+	} else {
+		t.Logf("Checksum mismatch %v [expected]", err)
+	}
+}
+
+// TestRecordChecksum : record digests and read them back from the install location
+func TestRecordChecksum(t *testing.T) {
+
+	installLocation := t.TempDir()
+
+	if err := lib.RecordChecksum(installLocation, "terragrunt_0.26.7", testBinaryDigest); err != nil {
+		t.Fatalf("Unable to record checksum %v [unexpected]", err)
+	}
+	if err := lib.RecordChecksum(installLocation, "terragrunt_0.26.6", strings.Repeat("b", 64)); err != nil {
+		t.Fatalf("Unable to record checksum %v [unexpected]", err)
+	}
+
+	checksums, err := lib.ReadChecksums(filepath.Join(installLocation, "SHA256SUMS"))
+	if err != nil {
+		t.Fatalf("Unable to read checksums %v [unexpected]", err)
+	}
+
+	if len(checksums) != 2 || checksums["terragrunt_0.26.7"] != testBinaryDigest {
+		t.Errorf("Unexpected recorded checksums %v [unexpected]", checksums)
+	} else {
+		t.Logf("Recorded checksums %v [expected]", checksums)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"testing"
 )
 
 func checkFileExist(file string) bool {
@@ -79,3 +80,21 @@ func removeContents(dir string) error {
 	}
 	return nil
 }
+
+// newTestInstallLocation : point HOME to a temporary directory and create fake binaries for the versions
+// in its install location. Returns the install location
+func newTestInstallLocation(t *testing.T, versions ...string) string {
+	t.Setenv("HOME", t.TempDir())
+
+	installLocation := filepath.Join(os.Getenv("HOME"), ".terragrunt.versions")
+	if err := os.MkdirAll(installLocation, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, version := range versions {
+		if err := os.WriteFile(filepath.Join(installLocation, getInstallFile("terragrunt_"+version)), []byte(testBinaryContent), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return installLocation
+}
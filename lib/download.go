@@ -1,47 +1,120 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
+// downloadClient : http client used for release downloads. Connecting and waiting for the response
+// time out, reading the body is only bounded by the context of the download
+var downloadClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: 30 * time.Second}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+	},
+}
+
 // DownloadFromURL : Downloads the binary from the source url
 func DownloadFromURL(installLocation string, url string) (string, error) {
+	return DownloadFromURLContext(context.Background(), installLocation, url)
+}
+
+// DownloadFromURLContext : Downloads the binary from the source url until the context is done.
+// The binary is written to a temporary file first, so an interrupted or failed download never
+// leaves a partial file behind
+func DownloadFromURLContext(ctx context.Context, installLocation string, url string) (string, error) {
 	tokens := strings.Split(url, "/")
 	fileName := tokens[len(tokens)-1]
-	fmt.Printf("Downloading to: %s\n", installLocation)
+	fmt.Fprintf(output, "Downloading to: %s\n", installLocation)
 
-	response, err := http.Get(url)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		fmt.Println("[Error] : Error while downloading", url, "-", err)
-		return "", err
+		return "", &DownloadError{URL: url, Err: err}
+	}
+
+	response, err := downloadClient.Do(request)
+	if err != nil {
+		return "", downloadError(ctx, url, err)
 	}
 	defer response.Body.Close()
 
 	if response.StatusCode != 200 {
 		//Sometimes hashicorp terraform file names are not consistent
 		//For example 0.12.0-alpha4 naming convention in the release repo is not consistent
-		return "", fmt.Errorf("[Error] : Unable to download from %s", url)
+		return "", &DownloadError{URL: url, Err: fmt.Errorf("unexpected response %s", response.Status)}
 	}
 
-	binFile := filepath.Join(installLocation, fileName)
-	output, err := os.Create(binFile)
+	tmp, err := ioutil.TempFile(installLocation, "."+fileName+".*.download")
 	if err != nil {
-		fmt.Println("[Error] : Error while creating", binFile, "-", err)
-		return "", err
+		return "", pathError(installLocation, err)
 	}
-	defer output.Close()
+	tmpFile := tmp.Name()
 
-	n, err := io.Copy(output, response.Body)
+	n, err := io.Copy(tmp, response.Body)
+	if errClose := tmp.Close(); err == nil {
+		err = errClose
+	}
 	if err != nil {
-		fmt.Println("[Error] : Error while downloading", url, "-", err)
-		return "", err
+		os.Remove(tmpFile) //remove partial download
+		return "", downloadError(ctx, url, err)
+	}
+
+	binFile := filepath.Join(installLocation, fileName)
+	if err := os.Rename(tmpFile, binFile); err != nil {
+		os.Remove(tmpFile)
+		return "", pathError(binFile, err)
 	}
 
-	fmt.Println(n, "bytes downloaded")
+	fmt.Fprintln(output, n, "bytes downloaded")
 	return binFile, nil
 }
+
+// DownloadBytes : Downloads a small release asset, such as a checksum or signature file, into memory
+func DownloadBytes(url string) ([]byte, error) {
+	return DownloadBytesContext(context.Background(), url)
+}
+
+// DownloadBytesContext : Downloads a small release asset into memory until the context is done
+func DownloadBytesContext(ctx context.Context, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, &DownloadError{URL: url, Err: err}
+	}
+
+	response, err := downloadClient.Do(request)
+	if err != nil {
+		return nil, downloadError(ctx, url, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, &DownloadError{URL: url, Err: fmt.Errorf("unexpected response %s", response.Status)}
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, downloadError(ctx, url, err)
+	}
+	return body, nil
+}
+
+// downloadError : report a cancelled download as the cancellation itself, any other failure as a DownloadError
+func downloadError(ctx context.Context, url string, err error) error {
+	if ctx.Err() == context.Canceled {
+		return ctx.Err()
+	}
+	return &DownloadError{URL: url, Err: err}
+}
@@ -1,12 +1,19 @@
 package lib_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"os/user"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/Swahjak/terragrunt-switcher/lib"
@@ -86,3 +93,90 @@ func TestDownloadFromURL_Valid(t *testing.T) {
 		t.Logf("Valid URL from %v [expected]", url)
 	}
 }
+
+// TestDownloadFromURLContext_Cancel : cancel a download half way and check no partial file is left behind
+func TestDownloadFromURLContext_Cancel(t *testing.T) {
+
+	installLocation := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1048576")
+		w.Write([]byte("partial terragrunt binary"))
+		w.(http.Flusher).Flush()
+		cancel() //interrupt the download once the first bytes are written
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	_, err := lib.DownloadFromURLContext(ctx, installLocation, server.URL+"/v0.26.7/terragrunt_linux_amd64")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected cancelled download, got %v [unexpected]", err)
+	}
+
+	files, _ := ioutil.ReadDir(installLocation)
+	if len(files) != 0 {
+		t.Errorf("Partial download left behind: %s [unexpected]", files[0].Name())
+	} else {
+		t.Log("No partial download left behind [expected]")
+	}
+}
+
+// TestDownloadFromURLContext_Failure : a truncated download returns an error and leaves no partial file behind
+func TestDownloadFromURLContext_Failure(t *testing.T) {
+
+	installLocation := t.TempDir()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1048576")
+		w.Write([]byte("truncated terragrunt binary"))
+	}))
+	defer server.Close()
+
+	_, err := lib.DownloadFromURLContext(context.Background(), installLocation, server.URL+"/v0.26.7/terragrunt_linux_amd64")
+
+	var downloadErr *lib.DownloadError
+	if !errors.As(err, &downloadErr) {
+		t.Errorf("Expected a download error, got %v [unexpected]", err)
+	}
+
+	files, _ := ioutil.ReadDir(installLocation)
+	if len(files) != 0 {
+		t.Errorf("Partial download left behind: %s [unexpected]", files[0].Name())
+	}
+}
+
+// TestDownloadFromURLContext_Complete : a complete download is available under its final name
+func TestDownloadFromURLContext_Complete(t *testing.T) {
+
+	installLocation := t.TempDir()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testBinaryContent))
+	}))
+	defer server.Close()
+
+	var messages bytes.Buffer
+	lib.SetOutput(&messages)
+	defer lib.SetOutput(os.Stdout)
+
+	installedFile, err := lib.DownloadFromURLContext(context.Background(), installLocation, server.URL+"/v0.26.7/terragrunt_linux_amd64")
+	if err != nil {
+		t.Fatalf("Download not possible %v [unexpected]", err)
+	}
+
+	if !strings.Contains(messages.String(), fmt.Sprintf("%d bytes downloaded", len(testBinaryContent))) {
+		t.Errorf("Downloaded size not printed to the output, got %q [unexpected]", messages.String())
+	}
+
+	expectedFile := filepath.Join(installLocation, "terragrunt_linux_amd64")
+	if installedFile != expectedFile {
+		t.Errorf("Downloaded file %v mismatches expected file %v [unexpected]", installedFile, expectedFile)
+	}
+
+	files, _ := ioutil.ReadDir(installLocation)
+	if len(files) != 1 {
+		t.Errorf("Expected only the downloaded file, found %d files [unexpected]", len(files))
+	}
+}
@@ -0,0 +1,146 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// InvalidVersionError : the provided version is not a valid version format
+type InvalidVersionError struct {
+	Version string
+	Product string // name of the product, terragrunt when empty
+}
+
+func (e *InvalidVersionError) Error() string {
+	return fmt.Sprintf("invalid %s version format: %q. Format should be #.#.# or #.#.#-@# where # are numbers and @ are word characters", errorProduct(e.Product), e.Version)
+}
+
+// VersionNotFoundError : no version of the product exists for the provided version or constraint
+type VersionNotFoundError struct {
+	Version       string
+	Product       string // name of the product, terragrunt when empty
+	InstalledOnly bool   // only the installed versions were searched, offline or without network
+}
+
+func (e *VersionNotFoundError) Error() string {
+	if e.InstalledOnly {
+		return fmt.Sprintf("no installed %s version matches %q and the version list is offline or unreachable. Install it while online, or see the installed versions with `tgswitch %slist`", errorProduct(e.Product), e.Version, productOption(e.Product))
+	}
+	return fmt.Sprintf("%s version %q does not exist. Try `tgswitch %s-l` to see all available versions", errorProduct(e.Product), e.Version, productOption(e.Product))
+}
+
+// NotInstalledError : the requested version of the product has not been installed
+type NotInstalledError struct {
+	Version string
+	Product string // name of the product, terragrunt when empty
+}
+
+func (e *NotInstalledError) Error() string {
+	return fmt.Sprintf("%s version %q is not installed", errorProduct(e.Product), e.Version)
+}
+
+// errorProduct : the product named in an error message, terragrunt when not set
+func errorProduct(product string) string {
+	if product == "" {
+		return Terragrunt.Name
+	}
+	return product
+}
+
+// productOption : the tgswitch option selecting the product in the hints of error messages, empty for terragrunt
+func productOption(product string) string {
+	if product == "" || product == Terragrunt.Name {
+		return ""
+	}
+	return "--product " + product + " "
+}
+
+// DownloadError : a release asset or the version list could not be downloaded
+type DownloadError struct {
+	URL string
+	Err error
+}
+
+func (e *DownloadError) Error() string {
+	return fmt.Sprintf("unable to download %s: %s", e.URL, e.Err)
+}
+
+func (e *DownloadError) Unwrap() error {
+	return e.Err
+}
+
+// RateLimitError : the GitHub API refused the request until its rate limit resets
+type RateLimitError struct {
+	Reset         time.Time // zero when the API did not tell
+	Authenticated bool
+}
+
+func (e *RateLimitError) Error() string {
+	message := "GitHub API rate limit exceeded"
+	if !e.Reset.IsZero() {
+		message += fmt.Sprintf(", it resets at %s", e.Reset.Local().Format(time.RFC1123))
+	}
+	if !e.Authenticated {
+		message += fmt.Sprintf(". Set %s or GITHUB_TOKEN to raise the limit", GitHubTokenEnv)
+	}
+	return message
+}
+
+// PermissionError : tgswitch is not allowed to write to the provided path
+type PermissionError struct {
+	Path string
+	Err  error
+}
+
+func (e *PermissionError) Error() string {
+	return fmt.Sprintf("permission denied: %s: %s", e.Path, e.Err)
+}
+
+func (e *PermissionError) Unwrap() error {
+	return e.Err
+}
+
+// ChecksumError : the downloaded binary does not match the published checksum
+type ChecksumError struct {
+	File     string
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("checksum mismatch for %s: expected %s, got %s", e.File, e.Expected, e.Actual)
+}
+
+// SignatureError : the signature of the release checksums could not be verified
+type SignatureError struct {
+	URL string
+	Err error
+}
+
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("unable to verify signature of %s: %s", e.URL, e.Err)
+}
+
+func (e *SignatureError) Unwrap() error {
+	return e.Err
+}
+
+// pathError : wrap a file system error, reporting permission problems as a PermissionError
+func pathError(path string, err error) error {
+	if errors.Is(err, os.ErrPermission) {
+		return &PermissionError{Path: path, Err: err}
+	}
+	return err
+}
+
+// LockTimeoutError : another tgswitch process held the lock on the install location for too long
+type LockTimeoutError struct {
+	Path    string
+	Timeout time.Duration
+}
+
+func (e *LockTimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s waiting for another tgswitch process to release %s", e.Timeout, e.Path)
+}
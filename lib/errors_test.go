@@ -0,0 +1,119 @@
+package lib_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+// TestGetTGListDownloadError : an unreachable version list returns a DownloadError instead of exiting
+func TestGetTGListDownloadError(t *testing.T) {
+
+	newTestInstallLocation(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := lib.GetTGList(server.URL, true)
+
+	var downloadErr *lib.DownloadError
+	if errors.As(err, &downloadErr) {
+		t.Logf("Returned download error %v [expected]", err)
+	} else {
+		t.Errorf("Expected a download error, got %v [unexpected]", err)
+	}
+}
+
+// TestGetTGListInvalidBody : a version list that cannot be parsed returns a DownloadError
+func TestGetTGListInvalidBody(t *testing.T) {
+
+	newTestInstallLocation(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not json"))
+	}))
+	defer server.Close()
+
+	_, err := lib.GetTGList(server.URL, true)
+
+	var downloadErr *lib.DownloadError
+	if !errors.As(err, &downloadErr) {
+		t.Errorf("Expected a download error, got %v [unexpected]", err)
+	}
+}
+
+// TestSemVerParserVersionNotFound : a constraint without matching version returns a VersionNotFoundError
+func TestSemVerParserVersionNotFound(t *testing.T) {
+
+	tgConstraint := ">= 3.0"
+	_, err := lib.SemVerParser(&tgConstraint, []string{"0.26.7", "0.27.0"})
+
+	var notFound *lib.VersionNotFoundError
+	if errors.As(err, &notFound) && notFound.Version == tgConstraint {
+		t.Logf("Returned version not found error %v [expected]", err)
+	} else {
+		t.Errorf("Expected a version not found error, got %v [unexpected]", err)
+	}
+}
+
+// TestInstallVersionInvalidVersion : an invalid version is rejected before anything is downloaded
+func TestInstallVersionInvalidVersion(t *testing.T) {
+
+	_, err := lib.InstallVersion(context.Background(), "0.26", "http://127.0.0.1:0/")
+
+	var invalid *lib.InvalidVersionError
+	if errors.As(err, &invalid) {
+		t.Logf("Returned invalid version error %v [expected]", err)
+	} else {
+		t.Errorf("Expected an invalid version error, got %v [unexpected]", err)
+	}
+}
+
+// TestSwitchVersionNotInstalled : switching to a version that was never installed returns a NotInstalledError
+func TestSwitchVersionNotInstalled(t *testing.T) {
+
+	err := lib.SwitchVersion(context.Background(), "0.0.0-notinstalled1", t.TempDir()+"/terragrunt")
+
+	var notInstalled *lib.NotInstalledError
+	if errors.As(err, &notInstalled) {
+		t.Logf("Returned not installed error %v [expected]", err)
+	} else {
+		t.Errorf("Expected a not installed error, got %v [unexpected]", err)
+	}
+}
+
+// TestProductErrors : the errors name the product of the version, and the hints select it
+func TestProductErrors(t *testing.T) {
+
+	newTestInstallLocation(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"versions": [{"id": "1.6.0"}]}`))
+	}))
+	defer server.Close()
+
+	_, notFound := lib.OpenTofu.ResolveVersion("~> 1.7.0", server.URL)
+	_, invalid := lib.Terraform.InstallVersion(context.Background(), "1.5", "http://127.0.0.1:0/")
+	notInstalled := lib.OpenTofu.SwitchVersion(context.Background(), "1.6.0", t.TempDir()+"/tofu")
+
+	tests := []struct {
+		err      error
+		expected []string
+	}{
+		{notFound, []string{"tofu version", "tgswitch --product tofu -l"}},
+		{invalid, []string{"invalid terraform version"}},
+		{notInstalled, []string{"tofu version \"1.6.0\" is not installed"}},
+		{&lib.NotInstalledError{Version: "0.38.0"}, []string{"terragrunt version"}},
+	}
+	for _, test := range tests {
+		for _, expected := range test.expected {
+			if test.err == nil || !strings.Contains(test.err.Error(), expected) {
+				t.Errorf("Expected %q in %v [unexpected]", expected, test.err)
+			}
+		}
+	}
+}
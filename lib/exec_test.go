@@ -0,0 +1,49 @@
+package lib_test
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+// TestExecBinary : the binary receives the arguments and the environment, its exit code is the exit code of the process
+func TestExecBinary(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("the test binary is a shell script")
+	}
+
+	/* in the child process, replace the test binary with the script */
+	if script := os.Getenv("TGSWITCH_TEST_EXEC"); script != "" {
+		code, err := lib.ExecBinary(script, []string{"plan", "two words"}, append(os.Environ(), "TGSWITCH_TEST_VALUE=kept"))
+		t.Fatalf("ExecBinary returned %d %v", code, err)
+	}
+
+	script := filepath.Join(t.TempDir(), "terragrunt_0.38.0")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho \"$1|$2|$TGSWITCH_TEST_VALUE\"\nexit 7\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestExecBinary$")
+	cmd.Env = append(os.Environ(), "TGSWITCH_TEST_EXEC="+script)
+	out, err := cmd.Output()
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 7 {
+		t.Errorf("Expected exit code 7, got %v [unexpected]", err)
+	}
+	if string(out) != "plan|two words|kept\n" {
+		t.Errorf("Expected the arguments and the environment, got %q [unexpected]", out)
+	} else {
+		t.Logf("Binary ran with %q [expected]", out)
+	}
+
+	if _, err := lib.ExecBinary(filepath.Join(t.TempDir(), "missing"), nil, os.Environ()); err == nil {
+		t.Error("Missing binary should be reported [unexpected]")
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+// +build !windows
+
+package lib
+
+import (
+	"syscall"
+)
+
+// ExecBinary : replace the tgswitch process with the binary, passing the arguments and the environment as is,
+// so the exit code and the signals of the binary are the ones of the process. Only returns when the binary
+// cannot be started
+func ExecBinary(path string, args []string, env []string) (int, error) {
+	argv := append([]string{path}, args...)
+	if err := syscall.Exec(path, argv, env); err != nil {
+		return 1, pathError(path, err)
+	}
+	return 0, nil
+}
@@ -0,0 +1,30 @@
+package lib
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+)
+
+// ExecBinary : run the binary with the arguments and the environment as is and wait for it. Windows cannot replace
+// the process, so the exit code of the binary is returned for tgswitch to exit with. Ctrl-C reaches the binary,
+// tgswitch ignores it until the binary exits
+func ExecBinary(path string, args []string, env []string) (int, error) {
+	cmd := exec.Command(path, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = env
+
+	signal.Ignore(os.Interrupt)
+	defer signal.Reset(os.Interrupt)
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 1, pathError(path, err)
+	}
+	return 0, nil
+}
@@ -1,13 +1,14 @@
 package lib
 
 import (
+	"archive/zip"
 	"bufio"
 	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -15,26 +16,26 @@ import (
 )
 
 // RenameFile : rename file name
-func RenameFile(src string, dest string) {
+func RenameFile(src string, dest string) error {
 	err := os.Rename(src, dest)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return pathError(dest, err)
 	}
+	return nil
 }
 
 // RemoveFiles : remove file
-func RemoveFiles(src string) {
+func RemoveFiles(src string) error {
 	files, err := filepath.Glob(src)
 	if err != nil {
-
-		panic(err)
+		return err
 	}
 	for _, f := range files {
 		if err := os.Remove(f); err != nil {
-			panic(err)
+			return pathError(f, err)
 		}
 	}
+	return nil
 }
 
 // CheckFileExist : check if file exist in directory
@@ -46,25 +47,53 @@ func CheckFileExist(file string) bool {
 	return true
 }
 
-// MoveFile will decompress a zip archive, moving all files and folders
-// within the zip file (parameter 1) to an output directory (parameter 2).
+// MoveFile : move a file (parameter 1) to its destination (parameter 2).
+// The file is renamed when possible. Otherwise it is copied to a temporary file next to the
+// destination which is then renamed, so the destination never holds a partially written file.
 func MoveFile(src string, dest string) error {
+	if err := os.Rename(src, dest); err == nil {
+		return nil
+	}
+
 	inputFile, err := os.Open(src)
 	if err != nil {
 		return fmt.Errorf("Couldn't open source file: %s", err)
 	}
-	outputFile, err := os.Create(dest)
+	defer inputFile.Close()
+
+	info, err := inputFile.Stat()
+	if err != nil {
+		return fmt.Errorf("Couldn't open source file: %s", err)
+	}
+
+	outputFile, err := ioutil.TempFile(filepath.Dir(dest), "."+filepath.Base(dest)+".*.tmp")
 	if err != nil {
-		inputFile.Close()
 		return fmt.Errorf("Couldn't open dest file: %s", err)
 	}
-	defer outputFile.Close()
+	tmpFile := outputFile.Name()
+
 	_, err = io.Copy(outputFile, inputFile)
-	inputFile.Close()
+	if err == nil {
+		err = outputFile.Sync()
+	}
+	if errClose := outputFile.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = os.Chmod(tmpFile, info.Mode().Perm())
+	}
 	if err != nil {
+		os.Remove(tmpFile)
 		return fmt.Errorf("Writing to output file failed: %s", err)
 	}
+
+	if err := os.Rename(tmpFile, dest); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("Writing to output file failed: %s", err)
+	}
+
 	// The copy was successful, so now delete the original file
+	inputFile.Close()
 	err = os.Remove(src)
 	if err != nil {
 		return fmt.Errorf("Failed removing original file: %s", err)
@@ -72,16 +101,55 @@ func MoveFile(src string, dest string) error {
 	return nil
 }
 
+// ExtractBinary : extract the file named name from a zip archive to a temporary file in dir.
+// Returns the path of the extracted file, nothing is left behind when the extraction fails
+func ExtractBinary(archive string, name string, dir string) (string, error) {
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return "", fmt.Errorf("unable to open archive %s: %w", archive, err)
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() || path.Base(file.Name) != name {
+			continue
+		}
+
+		source, err := file.Open()
+		if err != nil {
+			return "", fmt.Errorf("unable to read %s from %s: %w", file.Name, archive, err)
+		}
+		defer source.Close()
+
+		extracted, err := ioutil.TempFile(dir, "."+strings.TrimSuffix(name, ".exe")+"_*.download")
+		if err != nil {
+			return "", pathError(dir, err)
+		}
+
+		_, err = io.Copy(extracted, source)
+		if errClose := extracted.Close(); err == nil {
+			err = errClose
+		}
+		if err != nil {
+			os.Remove(extracted.Name())
+			return "", fmt.Errorf("unable to extract %s from %s: %w", file.Name, archive, err)
+		}
+		return extracted.Name(), nil
+	}
+
+	return "", fmt.Errorf("%s not found in archive %s", name, archive)
+}
+
 //CreateDirIfNotExist : create directory if directory does not exist
-func CreateDirIfNotExist(dir string) {
+func CreateDirIfNotExist(dir string) error {
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		fmt.Printf("Creating directory for terragrunt binary at: %v\n", dir)
+		fmt.Fprintf(output, "Creating directory for the binaries at: %v\n", dir)
 		err = os.MkdirAll(dir, 0755)
 		if err != nil {
-			fmt.Printf("Unable to create directory for terragrunt binary at: %v", dir)
-			panic(err)
+			return pathError(dir, fmt.Errorf("unable to create directory for the binaries: %w", err))
 		}
 	}
+	return nil
 }
 
 //WriteLines : writes into file
@@ -98,7 +166,7 @@ func WriteLines(lines []string, path string) (err error) {
 	for _, item := range lines {
 		_, err := file.WriteString(strings.TrimSpace(item) + "\n")
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(output, err)
 			break
 		}
 	}
@@ -143,7 +211,7 @@ func IsDirEmpty(name string) bool {
 
 	f, err := os.Open(name)
 	if err != nil {
-		log.Fatal(err)
+		return exist
 	}
 	defer f.Close()
 
@@ -161,8 +229,7 @@ func CheckDirHasTGBin(dir, prefix string) bool {
 
 	files, err := ioutil.ReadDir(dir)
 	if err != nil {
-		log.Fatal(err)
-		//return exist, err
+		return exist
 	}
 	res := []string{}
 	for _, f := range files {
@@ -196,24 +263,22 @@ func GetFileName(configfile string) string {
 }
 
 // GetCurrentDirectory : return the current directory
-func GetCurrentDirectory() string {
+func GetCurrentDirectory() (string, error) {
 
 	dir, err := os.Getwd() //get current directory
 	if err != nil {
-		log.Printf("Failed to get current directory %v\n", err)
-		os.Exit(1)
+		return "", fmt.Errorf("failed to get current directory: %w", err)
 	}
-	return dir
+	return dir, nil
 }
 
 // GetHomeDirectory : return the home directory
-func GetHomeDirectory() string {
+func GetHomeDirectory() (string, error) {
 
 	homedir, errHome := homedir.Dir()
 	if errHome != nil {
-		log.Printf("Failed to get home directory %v\n", errHome)
-		os.Exit(1)
+		return "", fmt.Errorf("failed to get home directory: %w", errHome)
 	}
 
-	return homedir
+	return homedir, nil
 }
@@ -446,3 +446,36 @@ func TestConvertExecutableExt(t *testing.T) {
 		}
 	}
 }
+
+// TestMoveFile : move a file and check the destination is replaced and the source removed
+func TestMoveFile(t *testing.T) {
+
+	tempDir := t.TempDir()
+	src := filepath.Join(tempDir, "terragrunt_linux_amd64")
+	dest := filepath.Join(tempDir, "terragrunt_0.26.7")
+
+	if err := os.WriteFile(src, []byte("new binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dest, []byte("old binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.MoveFile(src, dest); err != nil {
+		t.Fatalf("Unable to move file %v [unexpected]", err)
+	}
+
+	content, _ := os.ReadFile(dest)
+	if string(content) != "new binary" {
+		t.Errorf("Destination not replaced: %q [unexpected]", content)
+	}
+
+	if checkFileExist(src) {
+		t.Error("Source file should be removed [unexpected]")
+	}
+
+	files, _ := filepath.Glob(filepath.Join(tempDir, ".*.tmp"))
+	if len(files) != 0 {
+		t.Errorf("Temporary files left behind %v [unexpected]", files)
+	}
+}
@@ -0,0 +1,145 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	GitHubTokenEnv         = "TGSWITCH_GITHUB_TOKEN" // token for the GitHub API, GITHUB_TOKEN is used when not set
+	GitHubHostEnv          = "TGSWITCH_GITHUB_HOST"  // host of a GitHub Enterprise server the token is sent to as well
+	githubAPIHost          = "api.github.com"
+	githubEnterpriseAPI    = "/api/v3/" // path of the API of a GitHub Enterprise server
+	maxVersionListPages    = 30         // pages of a paginated version list followed at most
+	githubRateLimitWarning = 5          // requests left before warning about the rate limit
+)
+
+// githubRelease : a release of the GitHub Releases API
+type githubRelease struct {
+	TagName     string    `json:"tag_name"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+	Assets      []struct {
+		Name string `json:"name"`
+	} `json:"assets"`
+}
+
+// parseGitHubReleases : get the releases of a page of the GitHub Releases API. Drafts and releases without assets yet
+// are skipped. A {"Versions": [...]} list is read as well, for version urls still pointing at such a list
+func parseGitHubReleases(body []byte) ([]Release, error) {
+	if trimmed := strings.TrimSpace(string(body)); strings.HasPrefix(trimmed, "{") {
+		var list ListVersion
+		if err := json.Unmarshal(body, &list); err != nil {
+			return nil, err
+		}
+		return versionReleases(list.Versions), nil
+	}
+
+	var page []githubRelease
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, err
+	}
+
+	releases := make([]Release, 0, len(page))
+	for _, release := range page {
+		if release.Draft || len(release.Assets) == 0 {
+			continue
+		}
+		version := strings.TrimPrefix(release.TagName, "v")
+		assets := make([]string, 0, len(release.Assets))
+		for _, asset := range release.Assets {
+			assets = append(assets, asset.Name)
+		}
+		releases = append(releases, Release{
+			Version:    version,
+			Prerelease: release.Prerelease || strings.Contains(version, "-"),
+			Published:  release.PublishedAt,
+			Assets:     assets,
+		})
+	}
+	return releases, nil
+}
+
+// githubToken : the token for the GitHub API, from TGSWITCH_GITHUB_TOKEN or GITHUB_TOKEN
+func githubToken() string {
+	if token := os.Getenv(GitHubTokenEnv); token != "" {
+		return token
+	}
+	return os.Getenv("GITHUB_TOKEN")
+}
+
+// isGitHubAPI : check the url is the GitHub API, or the API of the GitHub Enterprise server set in
+// TGSWITCH_GITHUB_HOST. The token is only sent there, whatever host the version url or a next page link points to
+func isGitHubAPI(apiURL *url.URL) bool {
+	if apiURL.Host == githubAPIHost {
+		return true
+	}
+	enterpriseHost := os.Getenv(GitHubHostEnv)
+	return enterpriseHost != "" && strings.EqualFold(apiURL.Host, enterpriseHost) && strings.HasPrefix(apiURL.Path, githubEnterpriseAPI)
+}
+
+// setGitHubHeaders : ask the GitHub API for its JSON format, authenticated with the token when set
+func setGitHubHeaders(req *http.Request) {
+	if !isGitHubAPI(req.URL) {
+		return
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := githubToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// linkNext : the next page link of a Link header. Ex: <https://api.github.com/...&page=2>; rel="next"
+var linkNext = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="?next"?`)
+
+// nextPageURL : the url of the next page of a paginated response, empty on the last page
+func nextPageURL(res *http.Response) string {
+	for _, link := range res.Header.Values("Link") {
+		if match := linkNext.FindStringSubmatch(link); match != nil {
+			if next, err := res.Request.URL.Parse(match[1]); err == nil {
+				return next.String()
+			}
+		}
+	}
+	return ""
+}
+
+// rateLimitError : the error of a response refused by the rate limit of the GitHub API, nil for other responses
+func rateLimitError(res *http.Response) error {
+	if res.StatusCode != http.StatusForbidden && res.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+	retryAfter := res.Header.Get("Retry-After")
+	if res.Header.Get("X-RateLimit-Remaining") != "0" && retryAfter == "" {
+		return nil
+	}
+
+	err := &RateLimitError{Authenticated: githubToken() != ""}
+	if reset, parseErr := strconv.ParseInt(res.Header.Get("X-RateLimit-Reset"), 10, 64); parseErr == nil {
+		err.Reset = time.Unix(reset, 0)
+	} else if seconds, parseErr := strconv.Atoi(retryAfter); parseErr == nil {
+		err.Reset = time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	return err
+}
+
+// warnRateLimit : warn when few requests to the GitHub API are left before the rate limit
+func warnRateLimit(res *http.Response) {
+	remaining, err := strconv.Atoi(res.Header.Get("X-RateLimit-Remaining"))
+	if err != nil || remaining > githubRateLimitWarning {
+		return
+	}
+	hint := ""
+	if githubToken() == "" {
+		hint = fmt.Sprintf(", set %s or GITHUB_TOKEN to raise the limit", GitHubTokenEnv)
+	}
+	fmt.Fprintf(output, "[Warning] : %d requests to the GitHub API left before the rate limit%s\n", remaining, hint)
+}
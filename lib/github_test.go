@@ -0,0 +1,215 @@
+package lib_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+// githubReleasesPage1 : first page of the releases of terragrunt, as recorded from the GitHub Releases API
+const githubReleasesPage1 = `[
+  {
+    "url": "https://api.github.com/repos/gruntwork-io/terragrunt/releases/3",
+    "html_url": "https://github.com/gruntwork-io/terragrunt/releases/tag/v0.39.0",
+    "id": 3,
+    "tag_name": "v0.39.0",
+    "name": "v0.39.0",
+    "draft": true,
+    "prerelease": false,
+    "published_at": null,
+    "assets": []
+  },
+  {
+    "url": "https://api.github.com/repos/gruntwork-io/terragrunt/releases/2",
+    "html_url": "https://github.com/gruntwork-io/terragrunt/releases/tag/v0.38.12",
+    "id": 2,
+    "tag_name": "v0.38.12",
+    "name": "v0.38.12",
+    "draft": false,
+    "prerelease": true,
+    "created_at": "2022-10-04T18:21:57Z",
+    "published_at": "2022-10-04T19:02:11Z",
+    "assets": [
+      {"name": "SHA256SUMS", "size": 1198, "browser_download_url": "https://github.com/gruntwork-io/terragrunt/releases/download/v0.38.12/SHA256SUMS"},
+      {"name": "terragrunt_linux_amd64", "size": 41435136, "browser_download_url": "https://github.com/gruntwork-io/terragrunt/releases/download/v0.38.12/terragrunt_linux_amd64"}
+    ]
+  },
+  {
+    "url": "https://api.github.com/repos/gruntwork-io/terragrunt/releases/1",
+    "html_url": "https://github.com/gruntwork-io/terragrunt/releases/tag/v0.38.11",
+    "id": 1,
+    "tag_name": "v0.38.11",
+    "name": "v0.38.11",
+    "draft": false,
+    "prerelease": false,
+    "created_at": "2022-09-29T14:51:23Z",
+    "published_at": "2022-09-29T15:30:45Z",
+    "assets": [
+      {"name": "SHA256SUMS", "size": 1198, "browser_download_url": "https://github.com/gruntwork-io/terragrunt/releases/download/v0.38.11/SHA256SUMS"},
+      {"name": "terragrunt_linux_amd64", "size": 41431040, "browser_download_url": "https://github.com/gruntwork-io/terragrunt/releases/download/v0.38.11/terragrunt_linux_amd64"}
+    ]
+  }
+]`
+
+// githubReleasesPage2 : last page of the releases of terragrunt, with a release whose assets are not uploaded yet
+// and a release without a build for the platform
+const githubReleasesPage2 = `[
+  {
+    "tag_name": "v0.38.10",
+    "draft": false,
+    "prerelease": false,
+    "published_at": "2022-09-27T10:00:00Z",
+    "assets": []
+  },
+  {
+    "tag_name": "v0.38.9",
+    "draft": false,
+    "prerelease": false,
+    "published_at": "2022-09-23T09:00:00Z",
+    "assets": [{"name": "SHA256SUMS"}, {"name": "terragrunt_plan9_386"}]
+  },
+  {
+    "tag_name": "v0.38.9-rc1",
+    "draft": false,
+    "prerelease": false,
+    "published_at": "2022-09-20T08:12:00Z",
+    "assets": [{"name": "terragrunt_linux_amd64"}]
+  }
+]`
+
+// platformReleases : the recorded releases, with the linux amd64 builds named after the platform of the test
+func platformReleases(page string) string {
+	return strings.ReplaceAll(page, "terragrunt_linux_amd64", "terragrunt_"+runtime.GOOS+"_"+runtime.GOARCH)
+}
+
+// TestGitHubReleases : the releases are read from every page of the GitHub Releases API, skipping drafts
+func TestGitHubReleases(t *testing.T) {
+
+	newTestInstallLocation(t)
+	t.Setenv(lib.GitHubTokenEnv, "test-token")
+
+	requests := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("Expected the token to be sent, got %q [unexpected]", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(platformReleases(githubReleasesPage2)))
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s%s?per_page=3&page=2>; rel="next", <%s%s?per_page=3&page=2>; rel="last"`, server.URL, r.URL.Path, server.URL, r.URL.Path))
+		w.Write([]byte(platformReleases(githubReleasesPage1)))
+	}))
+	defer server.Close()
+	t.Setenv(lib.GitHubHostEnv, strings.TrimPrefix(server.URL, "http://"))
+	versionURL := server.URL + "/api/v3/repos/gruntwork-io/terragrunt/releases?per_page=3"
+
+	releases, err := lib.Terragrunt.ListReleases(versionURL, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	published, _ := time.Parse(time.RFC3339, "2022-09-29T15:30:45Z")
+	expected := []lib.Release{
+		{Version: "0.38.12", Prerelease: true},
+		{Version: "0.38.11", Published: published, Assets: []string{"SHA256SUMS", "terragrunt_" + runtime.GOOS + "_" + runtime.GOARCH}},
+		{Version: "0.38.9-rc1", Prerelease: true},
+	}
+	if len(releases) != len(expected) || !reflect.DeepEqual(releases[1], expected[1]) {
+		t.Errorf("Expected %+v, got %+v [unexpected]", expected, releases)
+	} else {
+		t.Logf("Read %d releases from %d pages [expected]", len(releases), requests)
+	}
+	for i := range expected {
+		if i < len(releases) && (releases[i].Version != expected[i].Version || releases[i].Prerelease != expected[i].Prerelease) {
+			t.Errorf("Expected %s, got %s [unexpected]", expected[i].Version, releases[i].Version)
+		}
+	}
+
+	if versions, err := lib.Terragrunt.ListVersions(versionURL, false); err != nil || !reflect.DeepEqual(versions, []string{"0.38.11"}) {
+		t.Errorf("Expected the stable 0.38.11 only, got %v %v [unexpected]", versions, err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected the cached list to be used, got %d requests [unexpected]", requests)
+	}
+}
+
+// TestGitHubRateLimit : a request refused by the rate limit of the GitHub API is reported with the time it resets
+func TestGitHubRateLimit(t *testing.T) {
+
+	newTestInstallLocation(t)
+	t.Setenv(lib.GitHubTokenEnv, "")
+	t.Setenv("GITHUB_TOKEN", "")
+
+	reset := time.Now().Add(10 * time.Minute).Truncate(time.Second)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("Expected no token, got %q [unexpected]", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message": "API rate limit exceeded for 127.0.0.1.", "documentation_url": "https://docs.github.com/rest/overview/resources-in-the-rest-api#rate-limiting"}`))
+	}))
+	defer server.Close()
+
+	_, err := lib.Terragrunt.ListVersions(server.URL+"/api/v3/repos/gruntwork-io/terragrunt/releases", false)
+	var rateLimitErr *lib.RateLimitError
+	var downloadErr *lib.DownloadError
+	if !errors.As(err, &rateLimitErr) || !errors.As(err, &downloadErr) || !rateLimitErr.Reset.Equal(reset) || rateLimitErr.Authenticated {
+		t.Errorf("Expected a rate limit error resetting at %s, got %v [unexpected]", reset, err)
+	} else {
+		t.Logf("Returned %v [expected]", err)
+	}
+}
+
+// TestGitHubToken : the token is only sent to the GitHub API and to the GitHub Enterprise server that is set,
+// not to another host serving the same API or linked as the next page
+func TestGitHubToken(t *testing.T) {
+
+	newTestInstallLocation(t)
+	t.Setenv(lib.GitHubTokenEnv, "")
+	t.Setenv("GITHUB_TOKEN", "test-token")
+
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("Token sent to %s [unexpected]", r.Host)
+		}
+		w.Write([]byte(githubReleasesPage2))
+	}))
+	defer mirror.Close()
+
+	authorized := 0
+	enterprise := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer test-token" {
+			authorized++
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s/api/v3/repos/gruntwork-io/terragrunt/releases?page=2>; rel="next"`, mirror.URL))
+		w.Write([]byte(githubReleasesPage1))
+	}))
+	defer enterprise.Close()
+	versionURL := enterprise.URL + "/api/v3/repos/gruntwork-io/terragrunt/releases"
+
+	if _, err := lib.Terragrunt.ListVersions(mirror.URL+"/api/v3/repos/gruntwork-io/terragrunt/releases", false); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(lib.GitHubHostEnv, strings.TrimPrefix(enterprise.URL, "http://"))
+	if _, err := lib.Terragrunt.ListVersions(versionURL, false); err != nil {
+		t.Fatal(err)
+	}
+	if authorized != 1 {
+		t.Errorf("Expected the token to be sent to the GitHub Enterprise server once, got %d [unexpected]", authorized)
+	}
+}
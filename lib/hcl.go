@@ -0,0 +1,429 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl2/hcl"
+	"github.com/hashicorp/hcl2/hcl/hclsyntax"
+	"github.com/hashicorp/hcl2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+const (
+	versionConstraintAttribute   = "terragrunt_version_constraint"
+	terraformConstraintAttribute = "terraform_version_constraint"
+)
+
+// hclSource : a version constraint attribute of a terragrunt configuration
+type hclSource struct {
+	fileName  string
+	attribute string
+	stopAt    string
+}
+
+// NewHCLSource : a source reading the version constraint from the terragrunt_version_constraint attribute of
+// the nearest terragrunt configuration in the directory or its parents, up to stopAt. See FindInParentFolders.
+// When the configuration does not set the attribute, it is read from the files pulled in with include blocks
+func NewHCLSource(fileName string, stopAt string) VersionSource {
+	return NewHCLAttributeSource(fileName, versionConstraintAttribute, stopAt)
+}
+
+// NewHCLAttributeSource : a source reading the version constraint from an attribute of the nearest terragrunt
+// configuration, such as terraform_version_constraint. See NewHCLSource
+func NewHCLAttributeSource(fileName string, attribute string, stopAt string) VersionSource {
+	return &hclSource{fileName: fileName, attribute: attribute, stopAt: stopAt}
+}
+
+func (source *hclSource) Name() string {
+	return source.fileName
+}
+
+func (source *hclSource) Lookup(dir string) (string, string, error) {
+	path, found := FindInParentFolders(dir, source.fileName, source.stopAt)
+	if !found {
+		return "", filepath.Join(dir, source.fileName), nil
+	}
+
+	return newTerragruntConfig(path, hclparse.NewParser(), source.stopAt, map[string]bool{}).versionConstraint(source.attribute)
+}
+
+// terragruntConfig : a terragrunt configuration file and the context its expressions are evaluated in.
+// Only locals, string templates and the functions of evalContext are supported, this is not a full terragrunt
+type terragruntConfig struct {
+	path     string // the configuration tgswitch looks up, included files are evaluated in its context like terragrunt does
+	parser   *hclparse.Parser
+	stopAt   string
+	includes []include
+	contexts map[string]*hcl.EvalContext // evaluation context of each file, with its locals
+	reading  map[string]bool             // files being read with read_terragrunt_config, to detect cycles
+}
+
+// include : an include block of a terragrunt configuration
+type include struct {
+	name          string // label of the block, empty for an include block without label
+	path          string
+	mergeStrategy string // no_merge, shallow or deep
+}
+
+func newTerragruntConfig(path string, parser *hclparse.Parser, stopAt string, reading map[string]bool) *terragruntConfig {
+	return &terragruntConfig{
+		path:     path,
+		parser:   parser,
+		stopAt:   stopAt,
+		contexts: map[string]*hcl.EvalContext{},
+		reading:  reading,
+	}
+}
+
+// versionConstraint : get a version constraint attribute of the configuration and the file that sets it.
+// Like terragrunt merges included configurations, an attribute of the configuration overrides the included
+// files, and a later include overrides an earlier one. Includes of included files are not followed, terragrunt
+// only supports one level of include
+func (config *terragruntConfig) versionConstraint(name string) (string, string, error) {
+
+	body, err := config.parse(config.path)
+	if err != nil {
+		return "", config.path, err
+	}
+
+	/* an include that cannot be evaluated only matters when the configuration does not set the attribute */
+	includes, includeErr := config.evalIncludes(body)
+	config.includes = includes
+
+	constraint, defined, err := config.attribute(body, config.path, name)
+	if err != nil || defined {
+		return constraint, config.path, err
+	}
+	if includeErr != nil {
+		return "", config.path, includeErr
+	}
+
+	location := config.path
+	for _, included := range config.includes {
+		if included.mergeStrategy == "no_merge" {
+			continue
+		}
+
+		includedBody, err := config.parse(included.path)
+		if err != nil {
+			return "", included.path, err
+		}
+		value, defined, err := config.attribute(includedBody, included.path, name)
+		if err != nil {
+			return "", included.path, err
+		}
+		if defined {
+			constraint, location = value, included.path
+		}
+	}
+	return constraint, location, nil
+}
+
+// parse : parse a terragrunt configuration in the native HCL syntax
+func (config *terragruntConfig) parse(path string) (*hclsyntax.Body, error) {
+	if !CheckFileExist(path) {
+		return nil, fmt.Errorf("unable to read %s: file not found", path)
+	}
+
+	file, diags := config.parser.ParseHCLFile(path)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("unable to parse HCL file: %w", diags)
+	}
+
+	body, isNative := file.Body.(*hclsyntax.Body)
+	if !isNative {
+		return nil, fmt.Errorf("unable to read %s: not a native HCL file", path)
+	}
+	return body, nil
+}
+
+// attribute : evaluate a version constraint attribute of the body of a file
+func (config *terragruntConfig) attribute(body *hclsyntax.Body, path string, name string) (string, bool, error) {
+	attribute, defined := body.Attributes[name]
+	if !defined {
+		return "", false, nil
+	}
+
+	value, diags := attribute.Expr.Value(config.evalContext(body, path))
+	if diags.HasErrors() {
+		return "", true, fmt.Errorf("unable to evaluate %s in %s: %w", name, path, diags)
+	}
+	if !value.IsWhollyKnown() {
+		return "", true, fmt.Errorf("unable to evaluate %s in %s: it depends on values tgswitch does not support", name, path)
+	}
+	if value.IsNull() || !value.Type().Equals(cty.String) {
+		return "", true, fmt.Errorf("%s in %s must be a string", name, path)
+	}
+	return strings.TrimSpace(value.AsString()), true, nil
+}
+
+// evalIncludes : evaluate the include blocks of the configuration, labeled or not, in the order they are defined.
+// Like terragrunt, includes are evaluated before the locals and cannot refer to them
+func (config *terragruntConfig) evalIncludes(body *hclsyntax.Body) ([]include, error) {
+
+	ctx := &hcl.EvalContext{Functions: config.functions(config.path)}
+	var includes []include
+	for _, block := range body.Blocks {
+		if block.Type != "include" {
+			continue
+		}
+
+		included := include{name: strings.Join(block.Labels, "."), mergeStrategy: "shallow"}
+		for name, attribute := range block.Body.Attributes {
+			if name != "path" && name != "merge_strategy" {
+				continue
+			}
+
+			value, diags := attribute.Expr.Value(ctx)
+			if diags.HasErrors() {
+				return nil, fmt.Errorf("unable to evaluate include %s in %s: %w", name, config.path, diags)
+			}
+			if !value.IsWhollyKnown() || value.IsNull() || !value.Type().Equals(cty.String) {
+				return nil, fmt.Errorf("include %s in %s must be a string", name, config.path)
+			}
+
+			if name == "path" {
+				included.path = value.AsString()
+			} else {
+				included.mergeStrategy = value.AsString()
+			}
+		}
+
+		if included.path == "" {
+			return nil, fmt.Errorf("include block without path in %s", config.path)
+		}
+		if !filepath.IsAbs(included.path) {
+			included.path = filepath.Join(filepath.Dir(config.path), included.path)
+		}
+		includes = append(includes, included)
+	}
+	return includes, nil
+}
+
+// evalContext : the context the expressions of a file are evaluated in, with the locals of the file and the
+// terragrunt functions tgswitch supports
+func (config *terragruntConfig) evalContext(body *hclsyntax.Body, path string) *hcl.EvalContext {
+
+	if ctx, cached := config.contexts[path]; cached {
+		return ctx
+	}
+
+	ctx := &hcl.EvalContext{Functions: config.functions(path)}
+	config.contexts[path] = ctx
+	ctx.Variables = map[string]cty.Value{"local": config.evalLocals(body, ctx)}
+	return ctx
+}
+
+// functions : the terragrunt and HCL functions tgswitch supports in the expressions of a file
+func (config *terragruntConfig) functions(path string) map[string]function.Function {
+	return map[string]function.Function{
+		"find_in_parent_folders":    config.findInParentFoldersFunc(),
+		"get_env":                   getEnvFunc,
+		"get_terragrunt_dir":        config.getTerragruntDirFunc(),
+		"get_parent_terragrunt_dir": config.getParentTerragruntDirFunc(path),
+		"read_terragrunt_config":    config.readTerragruntConfigFunc(),
+		"coalesce":                  stdlib.CoalesceFunc,
+		"format":                    stdlib.FormatFunc,
+		"join":                      stdlib.JoinFunc,
+		"jsondecode":                stdlib.JSONDecodeFunc,
+		"lookup":                    stdlib.LookupFunc,
+		"lower":                     stdlib.LowerFunc,
+		"merge":                     stdlib.MergeFunc,
+		"replace":                   stdlib.ReplaceFunc,
+		"split":                     stdlib.SplitFunc,
+		"trimspace":                 stdlib.TrimSpaceFunc,
+		"upper":                     stdlib.UpperFunc,
+	}
+}
+
+// evalLocals : evaluate the locals of a file. A local is evaluated once the locals it refers to are known.
+// Locals that cannot be evaluated, because they use unsupported functions or variables, are unknown:
+// they only fail the evaluation of the attributes that use them
+func (config *terragruntConfig) evalLocals(body *hclsyntax.Body, ctx *hcl.EvalContext) cty.Value {
+
+	pending := map[string]*hclsyntax.Attribute{}
+	for _, block := range body.Blocks {
+		if block.Type == "locals" {
+			for name, attribute := range block.Body.Attributes {
+				pending[name] = attribute
+			}
+		}
+	}
+
+	locals := map[string]cty.Value{}
+	for progress := true; progress; {
+		progress = false
+		for name, attribute := range pending {
+			if !localsKnown(attribute.Expr, locals, pending) {
+				continue
+			}
+
+			ctx.Variables = map[string]cty.Value{"local": cty.ObjectVal(locals)}
+			value, diags := attribute.Expr.Value(ctx)
+			if diags.HasErrors() {
+				value = cty.DynamicVal
+			}
+			locals[name] = value
+			delete(pending, name)
+			progress = true
+		}
+	}
+
+	/* what is left refers to missing locals or is part of a cycle */
+	for name := range pending {
+		locals[name] = cty.DynamicVal
+	}
+	return cty.ObjectVal(locals)
+}
+
+// localsKnown : true when every local the expression refers to, that is defined, was evaluated
+func localsKnown(expr hcl.Expression, locals map[string]cty.Value, pending map[string]*hclsyntax.Attribute) bool {
+	for _, traversal := range expr.Variables() {
+		if traversal.RootName() != "local" || len(traversal) < 2 {
+			continue
+		}
+		attribute, isAttribute := traversal[1].(hcl.TraverseAttr)
+		if !isAttribute {
+			continue
+		}
+		if _, evaluated := locals[attribute.Name]; !evaluated {
+			if _, defined := pending[attribute.Name]; defined {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// findInParentFoldersFunc : find_in_parent_folders([name], [fallback]) returns the path of the nearest file
+// named name, terragrunt.hcl by default, in the parent directories of the configuration. The fallback is
+// returned when the file is not found, without a fallback it is an error
+func (config *terragruntConfig) findInParentFoldersFunc() function.Function {
+	return function.New(&function.Spec{
+		VarParam: &function.Parameter{Name: "args", Type: cty.String},
+		Type:     function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			if len(args) > 2 {
+				return cty.NilVal, fmt.Errorf("find_in_parent_folders takes at most 2 arguments, got %d", len(args))
+			}
+
+			name := HCLFilename
+			if len(args) > 0 {
+				name = args[0].AsString()
+			}
+
+			if path, found := FindInParentFolders(filepath.Dir(filepath.Dir(config.path)), name, config.stopAt); found {
+				return cty.StringVal(path), nil
+			}
+			if len(args) == 2 {
+				return args[1], nil
+			}
+			return cty.NilVal, fmt.Errorf("unable to find %s in the parent folders of %s", name, config.path)
+		},
+	})
+}
+
+// getEnvFunc : get_env(name, [default]) returns the value of an environment variable, or the default when it is
+// not set. Without a default an unset variable is an error
+var getEnvFunc = function.New(&function.Spec{
+	VarParam: &function.Parameter{Name: "args", Type: cty.String},
+	Type:     function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		if len(args) < 1 || len(args) > 2 {
+			return cty.NilVal, fmt.Errorf("get_env takes 1 or 2 arguments, got %d", len(args))
+		}
+
+		if value, isSet := os.LookupEnv(args[0].AsString()); isSet {
+			return cty.StringVal(value), nil
+		}
+		if len(args) == 2 {
+			return args[1], nil
+		}
+		return cty.NilVal, fmt.Errorf("environment variable %s is not set", args[0].AsString())
+	},
+})
+
+// getTerragruntDirFunc : get_terragrunt_dir() returns the directory of the configuration
+func (config *terragruntConfig) getTerragruntDirFunc() function.Function {
+	return function.New(&function.Spec{
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return cty.StringVal(filepath.Dir(config.path)), nil
+		},
+	})
+}
+
+// getParentTerragruntDirFunc : get_parent_terragrunt_dir([include]) returns the directory of the included
+// configuration, the first include or the one with the label. In an included file it is the directory of that
+// file, without include the directory of the configuration
+func (config *terragruntConfig) getParentTerragruntDirFunc(path string) function.Function {
+	return function.New(&function.Spec{
+		VarParam: &function.Parameter{Name: "include", Type: cty.String},
+		Type:     function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			if path != config.path || len(config.includes) == 0 {
+				return cty.StringVal(filepath.Dir(path)), nil
+			}
+			if len(args) == 0 {
+				return cty.StringVal(filepath.Dir(config.includes[0].path)), nil
+			}
+
+			for _, included := range config.includes {
+				if included.name == args[0].AsString() {
+					return cty.StringVal(filepath.Dir(included.path)), nil
+				}
+			}
+			return cty.NilVal, fmt.Errorf("no include block named %s in %s", args[0].AsString(), config.path)
+		},
+	})
+}
+
+// readTerragruntConfigFunc : read_terragrunt_config(path, [default]) returns the top level attributes and the
+// locals of another configuration, relative paths are relative to the directory of the configuration.
+// Attributes that cannot be evaluated are unknown. The default is returned when the file does not exist
+func (config *terragruntConfig) readTerragruntConfigFunc() function.Function {
+	return function.New(&function.Spec{
+		Params:   []function.Parameter{{Name: "path", Type: cty.String}},
+		VarParam: &function.Parameter{Name: "default", Type: cty.DynamicPseudoType},
+		Type: func(args []cty.Value) (cty.Type, error) {
+			return cty.DynamicPseudoType, nil
+		},
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			path := args[0].AsString()
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(filepath.Dir(config.path), path)
+			}
+
+			if !CheckFileExist(path) && len(args) > 1 {
+				return args[1], nil
+			}
+			if config.reading[path] {
+				return cty.NilVal, fmt.Errorf("read_terragrunt_config of %s refers to itself", path)
+			}
+			config.reading[path] = true
+			defer delete(config.reading, path)
+
+			read := newTerragruntConfig(path, config.parser, config.stopAt, config.reading)
+			body, err := read.parse(path)
+			if err != nil {
+				return cty.NilVal, err
+			}
+
+			ctx := read.evalContext(body, path)
+			values := map[string]cty.Value{"locals": ctx.Variables["local"]}
+			for name, attribute := range body.Attributes {
+				value, diags := attribute.Expr.Value(ctx)
+				if diags.HasErrors() {
+					value = cty.DynamicVal
+				}
+				values[name] = value
+			}
+			return cty.ObjectVal(values), nil
+		},
+	})
+}
@@ -0,0 +1,165 @@
+package lib_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+// newTestTerragruntRepo : create a git repository with a root terragrunt.hcl and a leaf module directory
+func newTestTerragruntRepo(t *testing.T, root string, leaf string) (string, string) {
+	repo := t.TempDir()
+	leafDir := filepath.Join(repo, "prod", "eu-west-1", "vpc")
+	if err := os.MkdirAll(leafDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(repo, "terragrunt.hcl"), root)
+	writeTestFile(t, filepath.Join(leafDir, "terragrunt.hcl"), leaf)
+	return repo, leafDir
+}
+
+// TestHCLSourceInclude : the constraint is read from included files with terragrunt's merge semantics
+func TestHCLSourceInclude(t *testing.T) {
+
+	root := `terragrunt_version_constraint = ">= 0.38"`
+	tests := []struct {
+		name     string
+		leaf     string
+		version  string
+		fromRoot bool
+	}{
+		{"include without name", "include {\n  path = find_in_parent_folders()\n}\n", ">= 0.38", true},
+		{"include with interpolation", "include \"root\" {\n  path = \"${find_in_parent_folders(\"terragrunt.hcl\")}\"\n}\n", ">= 0.38", true},
+		{"leaf overrides include", "include {\n  path = find_in_parent_folders()\n}\nterragrunt_version_constraint = \"0.36.1\"\n", "0.36.1", false},
+		{"no_merge include", "include \"root\" {\n  path = find_in_parent_folders()\n  merge_strategy = \"no_merge\"\n}\n", "", false},
+		{"relative include", "include \"root\" {\n  path = \"../../../terragrunt.hcl\"\n}\n", ">= 0.38", true},
+		{"fallback", "include \"root\" {\n  path = find_in_parent_folders(\"missing.hcl\", \"../../../terragrunt.hcl\")\n}\n", ">= 0.38", true},
+		{"later include overrides", "include \"root\" {\n  path = find_in_parent_folders()\n}\ninclude \"env\" {\n  path = find_in_parent_folders(\"env.hcl\")\n}\n", "~> 0.40.0", false},
+	}
+
+	for _, test := range tests {
+		repo, leaf := newTestTerragruntRepo(t, root, test.leaf)
+		writeTestFile(t, filepath.Join(repo, "prod", "env.hcl"), `terragrunt_version_constraint = "~> 0.40.0"`)
+
+		version, location, err := lib.NewHCLSource(lib.HCLFilename, "").Lookup(leaf)
+		if err != nil {
+			t.Errorf("%s: unable to read constraint %v [unexpected]", test.name, err)
+			continue
+		}
+		if version != test.version {
+			t.Errorf("%s: expected %q, got %q [unexpected]", test.name, test.version, version)
+			continue
+		}
+		if test.fromRoot && location != filepath.Join(repo, "terragrunt.hcl") {
+			t.Errorf("%s: expected the root configuration as location, got %s [unexpected]", test.name, location)
+			continue
+		}
+		t.Logf("%s: %q from %s [expected]", test.name, version, location)
+	}
+}
+
+// TestHCLSourceIncludeNotFound : an include that cannot be found is reported
+func TestHCLSourceIncludeNotFound(t *testing.T) {
+
+	_, leaf := newTestTerragruntRepo(t, "", "include {\n  path = find_in_parent_folders(\"missing.hcl\")\n}\n")
+
+	if _, _, err := lib.NewHCLSource(lib.HCLFilename, "").Lookup(leaf); err != nil {
+		t.Logf("Missing include reported %v [expected]", err)
+	} else {
+		t.Error("Missing include should be reported [unexpected]")
+	}
+}
+
+// TestHCLSourceFixture : the constraint of the test-data configuration wins over its include, which is not looked up
+func TestHCLSourceFixture(t *testing.T) {
+
+	dir := filepath.Join("..", "test-data", "test_terragrunt_hcl")
+	version, _, err := lib.NewHCLSource(lib.HCLFilename, dir).Lookup(dir)
+	if err != nil || version != ">= 0.26.7, < 0.27" {
+		t.Errorf("Expected \">= 0.26.7, < 0.27\", got %q %v [unexpected]", version, err)
+	}
+}
+
+// TestHCLSourceExpressions : constraints computed from locals, templates and terragrunt functions
+func TestHCLSourceExpressions(t *testing.T) {
+
+	t.Setenv("TGSWITCH_TEST_CONSTRAINT", "~> 0.38.0")
+	tests := []struct {
+		name    string
+		root    string
+		leaf    string
+		version string
+	}{
+		{"local", "", "locals {\n  constraint = \">= 0.38\"\n}\nterragrunt_version_constraint = local.constraint\n", ">= 0.38"},
+		{"locals referring to locals", "", "locals {\n  version = \"${local.major}.38\"\n  major = \"0\"\n}\nterragrunt_version_constraint = \"~> ${local.version}.0\"\n", "~> 0.38.0"},
+		{"get_env", "", "terragrunt_version_constraint = get_env(\"TGSWITCH_TEST_CONSTRAINT\")\n", "~> 0.38.0"},
+		{"get_env default", "", "terragrunt_version_constraint = get_env(\"TGSWITCH_TEST_UNSET\", \"0.36.1\")\n", "0.36.1"},
+		{"unsupported local not used", "", "locals {\n  account = run_cmd(\"aws\", \"sts\")\n}\nterragrunt_version_constraint = \"0.36.1\"\n", "0.36.1"},
+		{"read_terragrunt_config", "", "locals {\n  versions = read_terragrunt_config(find_in_parent_folders(\"versions.hcl\"))\n}\nterragrunt_version_constraint = local.versions.locals.terragrunt\n", ">= 0.40"},
+		{"read_terragrunt_config attribute", "", "terragrunt_version_constraint = read_terragrunt_config(\"../../../versions.hcl\").constraint\n", "0.40.1"},
+		{"read_terragrunt_config default", "", "terragrunt_version_constraint = read_terragrunt_config(\"missing.hcl\", {constraint = \"0.36.1\"}).constraint\n", "0.36.1"},
+		{"root locals", "locals {\n  constraint = format(\">= %s\", \"0.38\")\n}\nterragrunt_version_constraint = local.constraint\n", "include {\n  path = find_in_parent_folders()\n}\n", ">= 0.38"},
+		{"get_parent_terragrunt_dir", "locals {\n  versions = read_terragrunt_config(\"${get_parent_terragrunt_dir()}/versions.hcl\")\n}\nterragrunt_version_constraint = local.versions.locals.terragrunt\n", "include \"root\" {\n  path = find_in_parent_folders()\n}\n", ">= 0.40"},
+		{"get_parent_terragrunt_dir in leaf", "", "include \"root\" {\n  path = find_in_parent_folders()\n}\nlocals {\n  versions = read_terragrunt_config(\"${get_parent_terragrunt_dir(\"root\")}/versions.hcl\")\n}\nterragrunt_version_constraint = local.versions.constraint\n", "0.40.1"},
+	}
+
+	for _, test := range tests {
+		repo, leaf := newTestTerragruntRepo(t, test.root, test.leaf)
+		writeTestFile(t, filepath.Join(repo, "versions.hcl"), "locals {\n  terragrunt = \">= 0.40\"\n}\nconstraint = \"0.40.1\"\n")
+
+		version, _, err := lib.NewHCLSource(lib.HCLFilename, "").Lookup(leaf)
+		if err != nil {
+			t.Errorf("%s: unable to read constraint %v [unexpected]", test.name, err)
+		} else if version != test.version {
+			t.Errorf("%s: expected %q, got %q [unexpected]", test.name, test.version, version)
+		} else {
+			t.Logf("%s: %q [expected]", test.name, version)
+		}
+	}
+}
+
+// TestHCLSourceUnsupported : a constraint that depends on what tgswitch cannot evaluate is reported, not ignored
+func TestHCLSourceUnsupported(t *testing.T) {
+
+	tests := map[string]string{
+		"unsupported function": "locals {\n  constraint = run_cmd(\"cat\", \"version\")\n}\nterragrunt_version_constraint = local.constraint\n",
+		"unknown local":        "terragrunt_version_constraint = local.missing\n",
+		"local cycle":          "locals {\n  a = local.b\n  b = local.a\n}\nterragrunt_version_constraint = local.a\n",
+		"unset environment":    "terragrunt_version_constraint = get_env(\"TGSWITCH_TEST_UNSET\")\n",
+		"read itself":          "terragrunt_version_constraint = read_terragrunt_config(\"terragrunt.hcl\").terragrunt_version_constraint\n",
+	}
+
+	for name, leaf := range tests {
+		_, dir := newTestTerragruntRepo(t, "", leaf)
+		if _, _, err := lib.NewHCLSource(lib.HCLFilename, "").Lookup(dir); err != nil {
+			t.Logf("%s: reported %v [expected]", name, err)
+		} else {
+			t.Errorf("%s: should be reported [unexpected]", name)
+		}
+	}
+}
+
+// TestTerraformVersionSources : the terraform constraint is read from terraform_version_constraint, following includes
+func TestTerraformVersionSources(t *testing.T) {
+
+	t.Setenv("TF_VERSION", "1.5.7")
+	_, leaf := newTestTerragruntRepo(t, "terraform_version_constraint = \">= 0.13, < 0.14\"\nterragrunt_version_constraint = \">= 0.38\"\n", "include \"root\" {\n  path = find_in_parent_folders()\n}\n")
+
+	resolution, err := lib.NewResolver(lib.TerraformVersionSources(lib.SourceOptions{})...).Resolve(leaf)
+	if err != nil || resolution.Version != ">= 0.13, < 0.14" || resolution.Source != lib.HCLFilename {
+		t.Errorf("Expected \">= 0.13, < 0.14\" from terragrunt.hcl, got %v %v [unexpected]", resolution, err)
+	} else {
+		t.Logf("Resolved %q from %s [expected]", resolution.Version, resolution.Location)
+	}
+
+	writeTestFile(t, filepath.Join(leaf, lib.TerraformVersionFilename), "1.5.6\n")
+	resolution, err = lib.NewResolver(lib.TerraformVersionSources(lib.SourceOptions{})...).Resolve(leaf)
+	if err != nil || resolution.Version != "1.5.6" {
+		t.Errorf("Expected 1.5.6 from .terraform-version, got %v %v [unexpected]", resolution, err)
+	}
+}
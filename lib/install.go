@@ -1,41 +1,38 @@
 package lib
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-	"log"
 	"os"
 	"os/user"
+	"path"
 	"path/filepath"
 	"runtime"
 	"strings"
-
-	"github.com/hashicorp/go-version"
 )
 
 const (
-	installFile               = "terragrunt"
-	versionPrefix             = "terragrunt_"
-	installPath               = ".terragrunt.versions"
-	recentFile                = "RECENT"
-	defaultBin                = "/usr/local/bin/terragrunt" //default bin installation dir
-	tfDarwinArm64StartVersion = "1.0.2"
+	recentFile = "RECENT"
+	linkDirs   = ".links" //directories with a link named after the product to one installed version
 )
 
 var (
 	installLocation = "/tmp"
 )
 
-// initialize : removes existing symlink to terragrunt binary// I Don't think this is needed
-func initialize() {
+// initialize : removes existing symlink to a version of the product installed by tgswitch found in the PATH,
+// unless it is the bin path tgswitch manages. The bin path itself is never removed, it is replaced atomically by ChangeSymlink
+func (product *Product) initialize(binPath string) error {
 
 	/* Step 1 */
 	/* initilize default binary path for terragrunt */
 	/* assumes that terragrunt is installed here */
 	/* we will find the terragrunt path instalation later and replace this variable with the correct installed bin path */
-	installedBinPath := "/usr/local/bin/terragrunt"
+	installedBinPath := product.DefaultBin
 
 	/* find terragrunt binary location if terragrunt is already installed*/
-	cmd := NewCommand("terragrunt")
+	cmd := NewCommand(product.Name)
 	next := cmd.Find()
 
 	/* overrride installation default binary path if terragrunt is already installed */
@@ -46,132 +43,375 @@ func initialize() {
 
 	/* check if current symlink to terragrunt binary exist */
 	symlinkExist := CheckSymlink(installedBinPath)
+	if !symlinkExist || installedBinPath == binPath {
+		return nil
+	}
+
+	/* only remove a symlink to a version tgswitch installed, never a shim or a link to a foreign binary */
+	installLocation, err := product.InstallLocation()
+	if err != nil {
+		return err
+	}
+	if !isInstalledLink(installedBinPath, installLocation) {
+		return nil
+	}
 
-	/* remove current symlink if exist*/
-	if symlinkExist {
-		RemoveSymlink(installedBinPath)
+	return RemoveSymlink(installedBinPath)
+}
+
+// isInstalledLink : whether the symlink lives outside the install location and resolves to a binary inside it.
+// Shims resolve to the tgswitch executable, and the link directories live in the install location
+func isInstalledLink(symlinkPath string, installLocation string) bool {
+	location, err := filepath.EvalSymlinks(installLocation)
+	if err != nil {
+		return false
+	}
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(symlinkPath)); err != nil || isInside(dir, location) {
+		return false
+	}
+	target, err := filepath.EvalSymlinks(symlinkPath)
+	if err != nil {
+		return false
 	}
 
+	return isInside(target, location)
+}
+
+// isInside : whether the path is inside the directory
+func isInside(path string, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
 }
 
 // GetInstallLocation : get location where the terragrunt binary will be installed,
 // will create a directory in the home location if it does not exist
-func GetInstallLocation() string {
-	/* get current user */
-	usr, errCurr := user.Current()
-	if errCurr != nil {
-		log.Fatal(errCurr)
-	}
+func GetInstallLocation() (string, error) {
+	return Terragrunt.InstallLocation()
+}
 
-	userCommon := usr.HomeDir
+// InstallLocation : get location where the versions of the product are installed,
+// will create a directory in the home location if it does not exist
+func (product *Product) InstallLocation() (string, error) {
+	/* get home directory of the current user */
+	userCommon, errHome := os.UserHomeDir()
+	if errHome != nil {
+		return "", errHome
+	}
 
 	/* set installation location */
-	installLocation = filepath.Join(userCommon, installPath)
+	installLocation = filepath.Join(userCommon, product.InstallPath)
 
 	/* Create local installation directory if it does not exist */
-	CreateDirIfNotExist(installLocation)
+	if err := CreateDirIfNotExist(installLocation); err != nil {
+		return "", err
+	}
+
+	return installLocation, nil
 
-	return installLocation
+}
 
+// GetInstalledVersionPath : get the path of the binary of an installed version
+func GetInstalledVersionPath(tgVersion string) (string, error) {
+	return Terragrunt.InstalledVersionPath(tgVersion)
 }
 
-//Install : Install the provided version in the argument
-func Install(tgVersion string, binPath string, mirrorURL string) {
+// InstalledVersionPath : get the path of the binary of an installed version of the product
+func (product *Product) InstalledVersionPath(version string) (string, error) {
+	installLocation, err := product.InstallLocation()
+	if err != nil {
+		return "", err
+	}
 
-	// if !ValidVersionFormat(tgVersion) {
-	// 	fmt.Printf("The provided terragrunt version format does not exist - %s. Try `tgswitch -l` to see all available versions.\n", tgVersion)
-	// 	os.Exit(1)
-	// }
+	return ConvertExecutableExt(filepath.Join(installLocation, product.VersionPrefix+version)), nil
+}
 
-	/* Check to see if user has permission to the default bin location which is  "/usr/local/bin/terragrunt"
-	 * If user does not have permission to default bin location, proceed to create $HOME/bin and install the tgswitch there
-	 * Inform user that they dont have permission to default location, therefore tgswitch was installed in $HOME/bin
-	 * Tell users to add $HOME/bin to their path
-	 */
-	binPath = InstallableBinLocation(binPath)
+// LinkDir : get a directory containing only a link named after the product to an installed version. Put in front
+// of PATH, the version runs without switching the bin path. The directory is kept for the next runs, and the version
+// is recorded as used
+func (product *Product) LinkDir(version string) (string, error) {
+	installFileVersionPath, err := product.InstalledVersionPath(version)
+	if err != nil {
+		return "", err
+	}
+	if !CheckFileExist(installFileVersionPath) {
+		return "", &NotInstalledError{Version: version, Product: product.Name}
+	}
 
-	initialize()                           //initialize path
-	installLocation = GetInstallLocation() //get installation location -  this is where we will put our terragrunt binary file
+	dir := filepath.Join(filepath.Dir(installFileVersionPath), linkDirs, version)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", pathError(dir, err)
+	}
+	if err := ReplaceSymlink(installFileVersionPath, filepath.Join(dir, ConvertExecutableExt(product.Name))); err != nil {
+		return "", err
+	}
 
-	goarch := runtime.GOARCH
-	goos := runtime.GOOS
+	if err := product.RecordUsage(version); err != nil { //the version runs from the directory, prune keeps it
+		fmt.Fprintf(output, "[Warning] : Unable to record version usage: %s\n", err)
+	}
+	return dir, nil
+}
 
-	// terragrunt darwin arm64 comes with 1.0.2 and next version
-	tfver, _ := version.NewVersion(tgVersion)
-	tf102, _ := version.NewVersion(tfDarwinArm64StartVersion)
-	if goos == "darwin" && goarch == "arm64" && tfver.LessThan(tf102) {
-		goarch = "amd64"
+// Install : Install the provided version in the argument and switch to it.
+// The install location stays locked until the switch is done
+func Install(ctx context.Context, tgVersion string, binPath string, mirrorURL string) error {
+	return Terragrunt.Install(ctx, tgVersion, binPath, mirrorURL)
+}
+
+// Install : install the provided version of the product and switch to it.
+// The install location stays locked until the switch is done
+func (product *Product) Install(ctx context.Context, version string, binPath string, mirrorURL string) error {
+
+	if !ValidVersionFormat(version) {
+		return &InvalidVersionError{Version: version, Product: product.Name}
 	}
 
+	lock, err := product.lockInstallLocation(ctx)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	if _, err := product.installVersion(ctx, version, mirrorURL); err != nil {
+		return err
+	}
+
+	return product.switchVersion(version, binPath)
+}
+
+// InstallVersion : Download the provided version to the install location without switching to it.
+// Returns the path of the installed binary. When the context is done the download is stopped and
+// partial files are removed
+func InstallVersion(ctx context.Context, tgVersion string, mirrorURL string) (string, error) {
+	return Terragrunt.InstallVersion(ctx, tgVersion, mirrorURL)
+}
+
+// InstallVersion : download the provided version of the product without switching to it.
+// Returns the path of the installed binary
+func (product *Product) InstallVersion(ctx context.Context, version string, mirrorURL string) (string, error) {
+
+	if !ValidVersionFormat(version) {
+		return "", &InvalidVersionError{Version: version, Product: product.Name}
+	}
+
+	lock, err := product.lockInstallLocation(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer lock.Release()
+
+	return product.installVersion(ctx, version, mirrorURL)
+}
+
+// installVersion : download the provided version, the caller holds the lock on the install location
+func (product *Product) installVersion(ctx context.Context, tgVersion string, mirrorURL string) (string, error) {
+
+	installLocation, err := product.InstallLocation() //get installation location -  this is where we will put our terragrunt binary file
+	if err != nil {
+		return "", err
+	}
+
+	goos := runtime.GOOS
+	goarch := product.releaseArch(tgVersion)
+
 	/* check if selected version already downloaded */
-	installFileVersionPath := ConvertExecutableExt(filepath.Join(installLocation, versionPrefix+tgVersion))
+	installFileVersionPath := ConvertExecutableExt(filepath.Join(installLocation, product.VersionPrefix+tgVersion))
 	fileExist := CheckFileExist(installFileVersionPath)
 
-	/* if selected version already exist, */
+	/* if selected version already exist, there is nothing to download */
 	if fileExist {
+		return installFileVersionPath, nil
+	}
 
-		/* remove current symlink if exist*/
-		symlinkExist := CheckSymlink(binPath)
+	/* proceed to download it from the release page */
+	assetURL := product.releaseURL(product.AssetURL, mirrorURL, tgVersion, goos, goarch)
+	checksumURL := product.releaseURL(product.ChecksumURL, mirrorURL, tgVersion, goos, goarch)
+	signatureURL := product.releaseURL(product.signatureURL(), mirrorURL, tgVersion, goos, goarch)
+	downloadedFile, errDownload := DownloadFromURLContext(ctx, installLocation, assetURL)
+	if errDownload != nil {
+		return "", errDownload
+	}
 
-		if symlinkExist {
-			RemoveSymlink(binPath)
+	lockedDigest := lockedDigests[product.VersionPrefix+tgVersion][goos+"_"+goarch]
+	digest, errVerify := verifyDownload(ctx, downloadedFile, checksumURL, signatureURL, path.Base(assetURL), lockedDigest)
+	if errVerify != nil {
+		RemoveFiles(downloadedFile)
+		if ctx.Err() == nil {
+			fmt.Fprintf(output, "Refusing to install %s version %q \n", product.Name, tgVersion)
 		}
-
-		/* set symlink to desired version */
-		CreateSymlink(installFileVersionPath, binPath)
-		fmt.Printf("Switched terragrunt to version %q \n", tgVersion)
-		AddRecent(tgVersion) //add to recent file for faster lookup
-		os.Exit(0)
+		return "", errVerify
 	}
+	fmt.Fprintf(output, "Verified checksum %s\n", digest)
 
-	//if does not have slash - append slash
-	hasSlash := strings.HasSuffix(mirrorURL, "/")
-	if !hasSlash {
-		mirrorURL = fmt.Sprintf("%s/", mirrorURL)
+	/* last chance to stop before the binary is put in place */
+	if err := ctx.Err(); err != nil {
+		RemoveFiles(downloadedFile)
+		return "", err
 	}
 
-	/* if selected version already exist, */
-	/* proceed to download it from the hashicorp release page */
-	url := mirrorURL + "v" + tgVersion + "/" + versionPrefix + goos + "_" + goarch
-	downloadedFile, errDownload := DownloadFromURL(installLocation, url)
+	/* the verified archive is only needed to get the binary out of it */
+	if product.Archive == "zip" {
+		archive := downloadedFile
+		downloadedFile, err = ExtractBinary(archive, ConvertExecutableExt(product.Name), installLocation)
+		RemoveFiles(archive)
+		if err != nil {
+			return "", err
+		}
+	}
 
-	/* If unable to download file from url, exit(1) immediately */
-	if errDownload != nil {
-		fmt.Println(errDownload)
-		os.Exit(1)
+	err = os.Chmod(downloadedFile, 0755)
+	if err != nil {
+		RemoveFiles(downloadedFile)
+		return "", pathError(downloadedFile, err)
 	}
 
-	/* unzip the downloaded zipfile */
+	/* rename the downloaded binary to its versioned name, the versioned binary appears complete or not at all */
 	errMove := MoveFile(downloadedFile, installFileVersionPath)
 	if errMove != nil {
-		fmt.Println("[Error] : Unable to unzip downloaded zip file")
-		log.Fatal(errMove)
-		os.Exit(1)
+		RemoveFiles(downloadedFile)
+		return "", pathError(installFileVersionPath, errMove)
+	}
+
+	/* record the verified digest next to the installed binary, for an archive the digest of the binary it contains */
+	if product.Archive != "" {
+		if digest, err = FileChecksum(installFileVersionPath); err != nil {
+			return installFileVersionPath, err
+		}
+	}
+	errRecord := RecordChecksum(installLocation, filepath.Base(installFileVersionPath), digest)
+	if errRecord != nil {
+		fmt.Fprintf(output, "[Warning] : Unable to record checksum: %s\n", errRecord)
 	}
 
-	err := os.Chmod(installFileVersionPath, 0755)
+	return installFileVersionPath, nil
+}
+
+// verifyDownload : verify the downloaded asset against the signed checksum file published with the release, and
+// against the digest of the lock file when the version is locked. Returns the verified digest
+func verifyDownload(ctx context.Context, downloadedFile string, checksumURL string, signatureURL string, assetName string, lockedDigest string) (string, error) {
+
+	/* verify the downloaded binary against the checksum published with the release */
+	checksums, err := getVerifiedChecksums(ctx, checksumURL, signatureURL)
 	if err != nil {
-		log.Println(err)
+		return "", err
+	}
+
+	digest, hasDigest := checksums[assetName]
+	if !hasDigest {
+		return "", fmt.Errorf("no checksum published for %s in %s", assetName, checksumURL)
 	}
 
-	/* remove current symlink if exist*/
-	symlinkExist := CheckSymlink(binPath)
+	/* a lock file pins the digest, the release must not have changed since it was locked */
+	if lockedDigest != "" && !strings.EqualFold(digest, lockedDigest) {
+		return "", &ChecksumError{File: assetName, Expected: lockedDigest, Actual: digest}
+	}
 
-	if symlinkExist {
-		RemoveSymlink(binPath)
+	if err := VerifyChecksum(downloadedFile, digest); err != nil {
+		return "", err
 	}
 
-	/* set symlink to desired version */
-	CreateSymlink(installFileVersionPath, binPath)
-	fmt.Printf("Switched terragrunt to version %q \n", tgVersion)
-	AddRecent(tgVersion) //add to recent file for faster lookup
-	os.Exit(0)
+	return digest, nil
+}
+
+// getVerifiedChecksums : download the checksum file of a release and verify it was signed by a trusted key.
+// Returns a map of file name to sha256 digest
+func getVerifiedChecksums(ctx context.Context, checksumURL string, signatureURL string) (map[string]string, error) {
+	checksumData, err := DownloadBytesContext(ctx, checksumURL)
+	if err != nil {
+		return nil, err
+	}
+
+	verifier := signatureVerifier
+	if verifier == nil {
+		if verifier, err = NewSignatureVerifier(nil, false); err != nil {
+			return nil, err
+		}
+	}
+	if err := verifier.VerifyURL(ctx, checksumURL, signatureURL, checksumData); err != nil {
+		return nil, err
+	}
+
+	return ParseChecksums(bytes.NewReader(checksumData))
+}
+
+// SwitchVersion : Point the binary path to an installed version
+func SwitchVersion(ctx context.Context, tgVersion string, binPath string) error {
+	return Terragrunt.SwitchVersion(ctx, tgVersion, binPath)
+}
+
+// SwitchVersion : point the binary path to an installed version of the product
+func (product *Product) SwitchVersion(ctx context.Context, version string, binPath string) error {
+
+	lock, err := product.lockInstallLocation(ctx)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	return product.switchVersion(version, binPath)
+}
+
+// switchVersion : point the binary path to an installed version, the caller holds the lock on the install location
+func (product *Product) switchVersion(tgVersion string, binPath string) error {
+
+	installFileVersionPath, err := product.InstalledVersionPath(tgVersion)
+	if err != nil {
+		return err
+	}
+
+	if !CheckFileExist(installFileVersionPath) {
+		return &NotInstalledError{Version: tgVersion, Product: product.Name}
+	}
+
+	/* Check to see if user has permission to the default bin location which is  "/usr/local/bin/terragrunt"
+	 * If user does not have permission to default bin location, proceed to create $HOME/bin and install the tgswitch there
+	 * Inform user that they dont have permission to default location, therefore tgswitch was installed in $HOME/bin
+	 * Tell users to add $HOME/bin to their path
+	 */
+	binPath, err = product.installableBinLocation(binPath)
+	if err != nil {
+		return err
+	}
+
+	/* the link is swapped atomically, terragrunt is available during the whole switch */
+	if err := ChangeSymlink(installFileVersionPath, binPath); err != nil {
+		return err
+	}
+	fmt.Fprintf(output, "Switched %s to version %q \n", product.Name, tgVersion)
+
+	if err := product.initialize(binPath); err != nil { //remove other symlinks to the product in the PATH
+		return err
+	}
+
+	if err := product.addRecent(tgVersion); err != nil { //add to recent file for faster lookup
+		fmt.Fprintf(output, "[Warning] : Unable to update recent versions: %s\n", err)
+	}
+
+	if err := recordUsage(filepath.Dir(installFileVersionPath), tgVersion); err != nil { //remember when the version was last used for pruning
+		fmt.Fprintf(output, "[Warning] : Unable to record version usage: %s\n", err)
+	}
+
+	return nil
 }
 
 // AddRecent : add to recent file
-func AddRecent(requestedVersion string) {
+func AddRecent(requestedVersion string) error {
+
+	lock, err := Terragrunt.lockInstallLocation(context.Background())
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	return Terragrunt.addRecent(requestedVersion)
+}
+
+// addRecent : add to recent file, the caller holds the lock on the install location
+func (product *Product) addRecent(requestedVersion string) error {
 
-	installLocation = GetInstallLocation() //get installation location -  this is where we will put our terragrunt binary file
+	installLocation, err := product.InstallLocation() //get installation location -  this is where we will put our terragrunt binary file
+	if err != nil {
+		return err
+	}
 	versionFile := filepath.Join(installLocation, recentFile)
 
 	fileExist := CheckFileExist(versionFile)
@@ -179,16 +419,16 @@ func AddRecent(requestedVersion string) {
 		lines, errRead := ReadLines(versionFile)
 
 		if errRead != nil {
-			fmt.Printf("[Error] : %s\n", errRead)
-			return
+			return errRead
 		}
 
 		for _, line := range lines {
 			if !ValidVersionFormat(line) {
-				fmt.Println("File dirty. Recreating cache file.")
-				RemoveFiles(versionFile)
-				CreateRecentFile(requestedVersion)
-				return
+				fmt.Fprintln(output, "File dirty. Recreating cache file.")
+				if err := RemoveFiles(versionFile); err != nil {
+					return err
+				}
+				return WriteLines([]string{requestedVersion}, versionFile)
 			}
 		}
 
@@ -199,22 +439,61 @@ func AddRecent(requestedVersion string) {
 				_, lines = lines[len(lines)-1], lines[:len(lines)-1]
 
 				lines = append([]string{requestedVersion}, lines...)
-				WriteLines(lines, versionFile)
-			} else {
-				lines = append([]string{requestedVersion}, lines...)
-				WriteLines(lines, versionFile)
+				return WriteLines(lines, versionFile)
 			}
+			lines = append([]string{requestedVersion}, lines...)
+			return WriteLines(lines, versionFile)
 		}
 
-	} else {
-		CreateRecentFile(requestedVersion)
+		return nil
+	}
+
+	return WriteLines([]string{requestedVersion}, versionFile)
+}
+
+// removeRecent : remove an uninstalled version from the recent file, the caller holds the lock on the install location
+func (product *Product) removeRecent(tgVersion string) error {
+
+	installLocation, err := product.InstallLocation()
+	if err != nil {
+		return err
+	}
+	versionFile := filepath.Join(installLocation, recentFile)
+
+	if !CheckFileExist(versionFile) {
+		return nil
+	}
+
+	lines, err := ReadLines(versionFile)
+	if err != nil {
+		return err
 	}
+
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line != tgVersion {
+			kept = append(kept, line)
+		}
+	}
+	if len(kept) == len(lines) {
+		return nil
+	}
+
+	return WriteLines(kept, versionFile)
 }
 
 // GetRecentVersions : get recent version from file
 func GetRecentVersions() ([]string, error) {
+	return Terragrunt.RecentVersions()
+}
+
+// RecentVersions : get the recent versions of the product from its recent file, marked with *recent
+func (product *Product) RecentVersions() ([]string, error) {
 
-	installLocation = GetInstallLocation() //get installation location -  this is where we will put our terragrunt binary file
+	installLocation, err := product.InstallLocation() //get installation location -  this is where we will put our terragrunt binary file
+	if err != nil {
+		return nil, err
+	}
 	versionFile := filepath.Join(installLocation, recentFile)
 
 	fileExist := CheckFileExist(versionFile)
@@ -224,7 +503,6 @@ func GetRecentVersions() ([]string, error) {
 		outputRecent := []string{}
 
 		if errRead != nil {
-			fmt.Printf("Error: %s\n", errRead)
 			return nil, errRead
 		}
 
@@ -234,8 +512,7 @@ func GetRecentVersions() ([]string, error) {
 			and the recent file will be removed
 			*/
 			if !ValidVersionFormat(line) {
-				RemoveFiles(versionFile)
-				return nil, errRead
+				return nil, RemoveFiles(versionFile)
 			}
 
 			/* 	output can be confusing since it displays the 3 most recent used terragrunt version
@@ -250,12 +527,43 @@ func GetRecentVersions() ([]string, error) {
 	return nil, nil
 }
 
+// readRecent : read the versions of the recent file without repairing it. Invalid lines are skipped
+func readRecent(installLocation string) ([]string, error) {
+
+	versionFile := filepath.Join(installLocation, recentFile)
+	if !CheckFileExist(versionFile) {
+		return nil, nil
+	}
+
+	lines, err := ReadLines(versionFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var recent []string
+	for _, line := range lines {
+		if ValidVersionFormat(line) {
+			recent = append(recent, line)
+		}
+	}
+	return recent, nil
+}
+
 //CreateRecentFile : create a recent file
-func CreateRecentFile(requestedVersion string) {
+func CreateRecentFile(requestedVersion string) error {
+
+	lock, err := Terragrunt.lockInstallLocation(context.Background())
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
 
-	installLocation = GetInstallLocation() //get installation location -  this is where we will put our terragrunt binary file
+	installLocation, err := GetInstallLocation() //get installation location -  this is where we will put our terragrunt binary file
+	if err != nil {
+		return err
+	}
 
-	WriteLines([]string{requestedVersion}, filepath.Join(installLocation, recentFile))
+	return WriteLines([]string{requestedVersion}, filepath.Join(installLocation, recentFile))
 }
 
 //ConvertExecutableExt : convert excutable with local OS extension
@@ -273,11 +581,16 @@ func ConvertExecutableExt(fpath string) string {
 
 //InstallableBinLocation : Checks if terragrunt is installable in the location provided by the user.
 //If not, create $HOME/bin. Ask users to add  $HOME/bin to $PATH and return $HOME/bin as install location
-func InstallableBinLocation(userBinPath string) string {
+func InstallableBinLocation(userBinPath string) (string, error) {
+	return Terragrunt.installableBinLocation(userBinPath)
+}
+
+// installableBinLocation : the bin path, or $HOME/bin/<product> when the bin path is not writable
+func (product *Product) installableBinLocation(userBinPath string) (string, error) {
 
 	usr, errCurr := user.Current()
 	if errCurr != nil {
-		log.Fatal(errCurr)
+		return "", errCurr
 	}
 
 	binDir := Path(userBinPath)           //get path directory from binary path
@@ -295,21 +608,20 @@ func InstallableBinLocation(userBinPath string) string {
 
 			homeBinExist := CheckDirExist(filepath.Join(usr.HomeDir, "bin")) //check to see if ~/bin exist
 			if homeBinExist {                                                //if ~/bin exist, install at ~/bin/terragrunt
-				fmt.Printf("Installing terragrunt at %s\n", filepath.Join(usr.HomeDir, "bin"))
-				return filepath.Join(usr.HomeDir, "bin", "terragrunt")
-			} else { //if ~/bin directory does not exist, create ~/bin for terragrunt installation
-				fmt.Printf("Unable to write to: %s\n", userBinPath)
-				fmt.Printf("Creating bin directory at: %s\n", filepath.Join(usr.HomeDir, "bin"))
-				CreateDirIfNotExist(filepath.Join(usr.HomeDir, "bin")) //create ~/bin
-				fmt.Printf("RUN `export PATH=$PATH:%s` to append bin to $PATH\n", filepath.Join(usr.HomeDir, "bin"))
-				return filepath.Join(usr.HomeDir, "bin", "terragrunt")
+				fmt.Fprintf(output, "Installing %s at %s\n", product.Name, filepath.Join(usr.HomeDir, "bin"))
+				return filepath.Join(usr.HomeDir, "bin", product.Name), nil
+			}
+			//if ~/bin directory does not exist, create ~/bin for terragrunt installation
+			fmt.Fprintf(output, "Unable to write to: %s\n", userBinPath)
+			fmt.Fprintf(output, "Creating bin directory at: %s\n", filepath.Join(usr.HomeDir, "bin"))
+			if err := CreateDirIfNotExist(filepath.Join(usr.HomeDir, "bin")); err != nil { //create ~/bin
+				return "", err
 			}
-		} else { // ELSE: the "/usr/local/bin" or custom path provided by user is writable, we will return installable location
-			return filepath.Join(userBinPath)
+			fmt.Fprintf(output, "RUN `export PATH=$PATH:%s` to append bin to $PATH\n", filepath.Join(usr.HomeDir, "bin"))
+			return filepath.Join(usr.HomeDir, "bin", product.Name), nil
 		}
+		// ELSE: the "/usr/local/bin" or custom path provided by user is writable, we will return installable location
+		return filepath.Join(userBinPath), nil
 	}
-	fmt.Printf("[Error] : Binary path does not exist: %s\n", userBinPath)
-	fmt.Printf("[Error] : Manually create bin directory at: %s and try again.\n", binDir)
-	os.Exit(1)
-	return ""
+	return "", fmt.Errorf("binary path does not exist: %s. Manually create bin directory at: %s and try again", userBinPath, binDir)
 }
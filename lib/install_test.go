@@ -1,10 +1,14 @@
 package lib_test
 
 import (
+	"context"
 	"os"
 	"os/user"
+	"path/filepath"
 	"runtime"
 	"testing"
+
+	"github.com/Swahjak/terragrunt-switcher/lib"
 )
 
 // TestAddRecent : Create a file, check filename exist,
@@ -38,3 +42,79 @@ func TestInstall(t *testing.T) {
 		},
 	)
 }
+
+// TestLinkDir : the link directory holds a link named after the product to the installed version, until it is uninstalled
+func TestLinkDir(t *testing.T) {
+
+	installLocation := newTestInstallLocation(t, "0.38.0")
+
+	dir, err := lib.Terragrunt.LinkDir("0.38.0")
+	if err != nil {
+		t.Fatalf("Unable to create the link directory %v [unexpected]", err)
+	}
+	target, err := filepath.EvalSymlinks(filepath.Join(dir, lib.ConvertExecutableExt("terragrunt")))
+	expected, _ := filepath.EvalSymlinks(filepath.Join(installLocation, getInstallFile("terragrunt_0.38.0")))
+	if err != nil || target != expected {
+		t.Errorf("Expected a link to %s, got %s %v [unexpected]", expected, target, err)
+	} else {
+		t.Logf("Link directory %s [expected]", dir)
+	}
+
+	if again, err := lib.Terragrunt.LinkDir("0.38.0"); err != nil || again != dir {
+		t.Errorf("Expected the link directory to be reused, got %s %v [unexpected]", again, err)
+	}
+
+	if _, err := lib.Terragrunt.LinkDir("0.37.0"); err == nil {
+		t.Error("Link to a version that is not installed should be reported [unexpected]")
+	}
+
+	if _, err := lib.Terragrunt.UninstallVersions(context.Background(), "0.38.0", filepath.Join(t.TempDir(), "terragrunt"), false, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected the link directory to be removed with the version, got %v [unexpected]", err)
+	}
+}
+
+// TestSwitchVersionKeepsForeignLinks : switching only removes links in the PATH to a version tgswitch installed,
+// shims and links to other binaries are kept
+func TestSwitchVersionKeepsForeignLinks(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on windows")
+	}
+	installLocation := newTestInstallLocation(t, "0.38.0")
+
+	foreign := filepath.Join(t.TempDir(), "terragrunt")
+	if err := os.WriteFile(foreign, []byte(testBinaryContent), 0755); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		target  string
+		removed bool
+	}{
+		{"link to an installed version", filepath.Join(installLocation, "terragrunt_0.38.0"), true},
+		{"link to a foreign binary", foreign, false},
+		{"shim", os.Args[0], false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			link := filepath.Join(dir, "terragrunt")
+			if err := os.Symlink(test.target, link); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("PATH", dir)
+
+			if err := lib.Terragrunt.SwitchVersion(context.Background(), "0.38.0", filepath.Join(t.TempDir(), "terragrunt")); err != nil {
+				t.Fatalf("Unable to switch %v [unexpected]", err)
+			}
+			if _, err := os.Lstat(link); os.IsNotExist(err) != test.removed {
+				t.Errorf("Expected the link to be removed: %v, got %v [unexpected]", test.removed, err)
+			} else {
+				t.Logf("Link removed: %v [expected]", test.removed)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// InstalledVersion : a terragrunt version found in the install location
+type InstalledVersion struct {
+	Version     string    `json:"version"`
+	Path        string    `json:"path"`
+	Size        int64     `json:"size"`
+	InstalledAt time.Time `json:"installed_at"`
+	LastUsed    time.Time `json:"last_used"` // last time this version was switched to or run, the install time if it never was
+	Current     bool      `json:"current"`   // the bin path points to this version
+	Recent      bool      `json:"recent"`    // the version is in the recent file
+}
+
+// StrayFile : a file in the install location that looks like it belongs to tgswitch but is not a usable version
+type StrayFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// ListInstalledVersions : scan the install location for installed versions, sorted from newest to oldest.
+// Files named like a terragrunt binary that cannot be used are returned as stray files
+func ListInstalledVersions(binPath string) ([]InstalledVersion, []StrayFile, error) {
+	return Terragrunt.ListInstalledVersions(binPath)
+}
+
+// ListInstalledVersions : scan the install location of the product for installed versions, sorted from newest
+// to oldest. Files named like a binary of the product that cannot be used are returned as stray files
+func (product *Product) ListInstalledVersions(binPath string) ([]InstalledVersion, []StrayFile, error) {
+
+	installLocation, err := product.InstallLocation()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files, err := ioutil.ReadDir(installLocation)
+	if err != nil {
+		return nil, nil, pathError(installLocation, err)
+	}
+
+	current, err := product.CurrentVersion(binPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	recent, err := readRecent(installLocation)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	usage, err := readUsage(installLocation)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byVersion := map[string]InstalledVersion{}
+	var versions []string
+	var strays []StrayFile
+	for _, file := range files {
+		path := filepath.Join(installLocation, file.Name())
+
+		if strings.HasPrefix(file.Name(), "."+product.VersionPrefix) && strings.HasSuffix(file.Name(), ".download") {
+			strays = append(strays, StrayFile{Path: path, Reason: "incomplete download"})
+			continue
+		}
+		if !strings.HasPrefix(file.Name(), product.VersionPrefix) {
+			continue
+		}
+
+		tgVersion, isVersion := product.installedVersionName(file.Name())
+		switch {
+		case !isVersion:
+			strays = append(strays, StrayFile{Path: path, Reason: "not a valid version"})
+		case !file.Mode().IsRegular():
+			strays = append(strays, StrayFile{Path: path, Reason: "not a regular file"})
+		case file.Size() == 0:
+			strays = append(strays, StrayFile{Path: path, Reason: "empty file"})
+		case runtime.GOOS != "windows" && file.Mode().Perm()&0111 == 0:
+			strays = append(strays, StrayFile{Path: path, Reason: "not executable"})
+		default:
+			lastUsed, used := usage[tgVersion]
+			if !used {
+				lastUsed = file.ModTime()
+			}
+
+			versions = append(versions, tgVersion)
+			byVersion[tgVersion] = InstalledVersion{
+				Version:     tgVersion,
+				Path:        path,
+				Size:        file.Size(),
+				InstalledAt: file.ModTime(),
+				LastUsed:    lastUsed,
+				Current:     tgVersion == current,
+				Recent:      VersionExist(tgVersion, recent),
+			}
+		}
+	}
+
+	installed := make([]InstalledVersion, 0, len(versions))
+	for _, version := range sortedVersions(versions) {
+		installed = append(installed, byVersion[version.Original()])
+	}
+	return installed, strays, nil
+}
+
+// GetInstalledVersions : list the versions found in the install location, sorted from newest to oldest
+func GetInstalledVersions() ([]string, error) {
+	return Terragrunt.InstalledVersions()
+}
+
+// InstalledVersions : list the installed versions of the product, sorted from newest to oldest
+func (product *Product) InstalledVersions() ([]string, error) {
+
+	installed, _, err := product.ListInstalledVersions("")
+	if err != nil {
+		return nil, err
+	}
+
+	tglist := make([]string, 0, len(installed))
+	for _, version := range installed {
+		tglist = append(tglist, version.Version)
+	}
+	return tglist, nil
+}
+
+// installedVersionName : get the version from the file name of an installed binary
+func (product *Product) installedVersionName(fileName string) (string, bool) {
+	if !strings.HasPrefix(fileName, product.VersionPrefix) {
+		return "", false
+	}
+
+	tgVersion := strings.TrimSuffix(strings.TrimPrefix(fileName, product.VersionPrefix), ".exe")
+	return tgVersion, ValidVersionFormat(tgVersion)
+}
+
+// CurrentVersion : get the installed version the bin path points to. When the bin path is not a
+// symlink to the install location, the $HOME/bin fallback used by InstallableBinLocation is checked.
+// Returns an empty version when no installed version is linked
+func CurrentVersion(binPath string) (string, error) {
+	return Terragrunt.CurrentVersion(binPath)
+}
+
+// CurrentVersion : get the installed version of the product the bin path points to, see CurrentVersion
+func (product *Product) CurrentVersion(binPath string) (string, error) {
+
+	installLocation, err := product.InstallLocation()
+	if err != nil {
+		return "", err
+	}
+
+	homedir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	for _, path := range []string{binPath, ConvertExecutableExt(filepath.Join(homedir, "bin", product.Name))} {
+		target, err := os.Readlink(path)
+		if err != nil {
+			continue
+		}
+
+		if filepath.Dir(target) != installLocation {
+			continue
+		}
+
+		if tgVersion, isVersion := product.installedVersionName(filepath.Base(target)); isVersion {
+			return tgVersion, nil
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,96 @@
+package lib_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+// TestGetInstalledVersions : installed versions are listed newest first, other files are ignored
+func TestGetInstalledVersions(t *testing.T) {
+
+	installLocation := newTestInstallLocation(t, "0.9.1", "0.38.0", "0.26.7")
+	createFile(filepath.Join(installLocation, "RECENT"))
+	createFile(filepath.Join(installLocation, "terragrunt_notaversion"))
+
+	installed, err := lib.GetInstalledVersions()
+	if err != nil {
+		t.Fatalf("Unable to list installed versions %v [unexpected]", err)
+	}
+
+	expected := []string{"0.38.0", "0.26.7", "0.9.1"}
+	if reflect.DeepEqual(installed, expected) {
+		t.Logf("Installed versions %v [expected]", installed)
+	} else {
+		t.Errorf("Expected installed versions %v, got %v [unexpected]", expected, installed)
+	}
+}
+
+// TestCurrentVersion : the current version is read from the bin path symlink
+func TestCurrentVersion(t *testing.T) {
+
+	installLocation := newTestInstallLocation(t, "0.38.0")
+	binPath := filepath.Join(t.TempDir(), "terragrunt")
+
+	current, err := lib.CurrentVersion(binPath)
+	if err != nil || current != "" {
+		t.Errorf("Expected no current version, got %q %v [unexpected]", current, err)
+	}
+
+	if err := os.Symlink(filepath.Join(installLocation, getInstallFile("terragrunt_0.38.0")), binPath); err != nil {
+		t.Fatal(err)
+	}
+
+	current, err = lib.CurrentVersion(binPath)
+	if err != nil {
+		t.Fatalf("Unable to get current version %v [unexpected]", err)
+	}
+	if current == "0.38.0" {
+		t.Logf("Current version %s [expected]", current)
+	} else {
+		t.Errorf("Expected current version 0.38.0, got %q [unexpected]", current)
+	}
+}
+
+// TestListInstalledVersions : installed versions are marked current and recent, unusable files are reported as stray
+func TestListInstalledVersions(t *testing.T) {
+
+	installLocation := newTestInstallLocation(t, "0.38.0", "0.26.7", "0.9.1")
+	binPath := filepath.Join(t.TempDir(), "terragrunt")
+	if err := os.Symlink(filepath.Join(installLocation, getInstallFile("terragrunt_0.38.0")), binPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := lib.AddRecent("0.26.7"); err != nil {
+		t.Fatal(err)
+	}
+
+	createFile(filepath.Join(installLocation, "terragrunt_0.5.0"))
+	createFile(filepath.Join(installLocation, "terragrunt_linux_amd64"))
+	createFile(filepath.Join(installLocation, ".terragrunt_linux_amd64.1234.download"))
+
+	installed, strays, err := lib.ListInstalledVersions(binPath)
+	if err != nil {
+		t.Fatalf("Unable to list installed versions %v [unexpected]", err)
+	}
+
+	if len(installed) != 3 {
+		t.Fatalf("Expected 3 installed versions, got %v [unexpected]", installed)
+	}
+	for _, version := range installed {
+		if version.Size != int64(len(testBinaryContent)) || version.InstalledAt.IsZero() {
+			t.Errorf("Missing size or install time for %v [unexpected]", version)
+		}
+		if version.Current != (version.Version == "0.38.0") || version.Recent != (version.Version == "0.26.7") {
+			t.Errorf("Wrong markers for %v [unexpected]", version)
+		}
+	}
+
+	if len(strays) == 3 {
+		t.Logf("Stray files %v [expected]", strays)
+	} else {
+		t.Errorf("Expected 3 stray files, got %v [unexpected]", strays)
+	}
+}
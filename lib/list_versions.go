@@ -1,155 +1,37 @@
 package lib
 
 import (
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"log"
-	"net/http"
-	"os"
 	"reflect"
 	"regexp"
 	"strings"
-	"time"
 )
 
-type tgVersionList struct {
-	tgList []string
-}
-
-//GetTGList :  Get the list of available terraform version given the hashicorp url
+//GetTGList :  Get the list of available terragrunt version given the version url
 func GetTGList(versionUrl string, preRelease bool) ([]string, error) {
-
-	var tgVersionList tgVersionList
-	result, error := GetTGURLBody(versionUrl)
-
-	if error != nil {
-		log.Println(error)
-		os.Exit(1)
-
-		return tgVersionList.tgList, error
-	}
-
-	var semver string
-	if preRelease == true {
-		// Getting versions from body; should return match /X.X.X-@/ where X is a number,@ is a word character between a-z or A-Z
-		semver = `^(\d+\.\d+\.\d+)(-[a-zA-z]+\d*)?$`
-	} else if preRelease == false {
-		// Getting versions from body; should return match /X.X.X/ where X is a number
-		semver = `^(\d+\.\d+\.\d+)$`
-	}
-
-	r, _ := regexp.Compile(semver)
-	for i := range result {
-		if r.MatchString(result[i]) {
-			str := r.FindString(result[i])
-			trimstr := strings.Trim(str, "/\"") //remove '/' or '"' from /X.X.X/" or /X.X.X"
-			tgVersionList.tgList = append(tgVersionList.tgList, trimstr)
-		}
-	}
-
-	if len(tgVersionList.tgList) == 0 {
-		fmt.Printf("Cannot get list from mirror: %s\n", versionUrl)
-	}
-
-	return tgVersionList.tgList, nil
-
+	return Terragrunt.ListVersions(versionUrl, preRelease)
 }
 
-//GetTGLatest :  Get the latest terraform version given the hashicorp url
+//GetTGLatest :  Get the latest stable terragrunt version given the version url
 func GetTGLatest(versionUrl string) (string, error) {
-
-	result, error := GetTGURLBody(versionUrl)
-	if error != nil {
-		return "", error
-	}
-	// Getting versions from body; should return match /X.X.X/ where X is a number
-	semver := `\/(\d+\.\d+\.\d+)\/?"`
-	r, _ := regexp.Compile(semver)
-	for i := range result {
-		if r.MatchString(result[i]) {
-			str := r.FindString(result[i])
-			trimstr := strings.Trim(str, "/\"") //remove '/' or '"' from /X.X.X/" or /X.X.X"
-			return trimstr, nil
-		}
-	}
-
-	return "", nil
+	return Terragrunt.LatestVersion(versionUrl)
 }
 
-//GetTGLatestImplicit :  Get the latest implicit terraform version given the hashicorp url
+//GetTGLatestImplicit :  Get the latest implicit terragrunt version given the version url
 func GetTGLatestImplicit(versionUrl string, preRelease bool, version string) (string, error) {
-
-	if preRelease == true {
-		//TODO: use GetTGList() instead of GetTGURLBody
-		versions, error := GetTGURLBody(versionUrl)
-		if error != nil {
-			return "", error
-		}
-		// Getting versions from body; should return match /X.X.X-@/ where X is a number,@ is a word character between a-z or A-Z
-		semver := fmt.Sprintf(`\/(%s{1}\.\d+\-[a-zA-z]+\d*)\/?"`, version)
-		r, err := regexp.Compile(semver)
-		if err != nil {
-			return "", err
-		}
-		for i := range versions {
-			if r.MatchString(versions[i]) {
-				str := r.FindString(versions[i])
-				trimstr := strings.Trim(str, "/\"") //remove '/' or '"' from /X.X.X/" or /X.X.X"
-				return trimstr, nil
-			}
-		}
-	} else if preRelease == false {
-		listAll := false
-		tgList, _ := GetTGList(versionUrl, listAll) //get list of versions
-		version = fmt.Sprintf("~> %v", version)
-		semv, err := SemVerParser(&version, tgList)
-		if err != nil {
-			return "", err
-		}
-		return semv, nil
-	}
-	return "", nil
+	return Terragrunt.LatestImplicitVersion(versionUrl, preRelease, version)
 }
 
-//GetTGURLBody : Get list of terraform versions from hashicorp releases
+//GetTGURLBody : Get list of terragrunt versions from the version url, without the version list cache
 func GetTGURLBody(versionUrl string) ([]string, error) {
-	gswitch := http.Client{
-		Timeout: time.Second * 10, // Maximum of 10 secs [decresing this seem to fail]
-	}
-
-	req, err := http.NewRequest(http.MethodGet, versionUrl, nil)
+	list, err := Terragrunt.fetchVersionList(versionUrl, nil)
 	if err != nil {
-		log.Println("[Error] Unable to make request. Please try again.")
-		os.Exit(1)
 		return nil, err
 	}
-
-	req.Header.Set("User-Agent", "github-appinstaller")
-
-	res, getErr := gswitch.Do(req)
-	if getErr != nil {
-		log.Println("[Error] Unable to make request Please try again.")
-		os.Exit(1)
-		return nil, getErr
+	versions := make([]string, 0, len(list.Releases))
+	for _, release := range list.Releases {
+		versions = append(versions, release.Version)
 	}
-
-	body, readErr := ioutil.ReadAll(res.Body)
-	if readErr != nil {
-		log.Println("[Error] Unable to get release from repo ", string(body))
-		os.Exit(1)
-		return nil, readErr
-	}
-
-	var repo ListVersion
-	jsonErr := json.Unmarshal(body, &repo)
-	if jsonErr != nil {
-		log.Println("[Error] Unable to get release from repo ", string(body))
-		os.Exit(1)
-		return nil, jsonErr
-	}
-
-	return repo.Versions, nil
+	return versions, nil
 }
 
 type ListVersion struct {
@@ -0,0 +1,93 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	lockFile           = ".lock"
+	lockPollInterval   = 100 * time.Millisecond
+	DefaultLockTimeout = 5 * time.Minute //how long to wait for another tgswitch process by default
+)
+
+var lockTimeout = DefaultLockTimeout
+
+// Lock : advisory lock on the install location. Only one tgswitch process at a time may
+// install versions, update the RECENT file or switch the bin symlink
+type Lock struct {
+	file *os.File
+}
+
+// SetLockTimeout : set how long to wait for another tgswitch process to release the lock
+func SetLockTimeout(timeout time.Duration) {
+	lockTimeout = timeout
+}
+
+// AcquireLock : lock the directory, waiting until the lock is released by another process,
+// the timeout expires or the context is done. A timeout of 0 fails immediately when the lock is held
+func AcquireLock(ctx context.Context, dir string, timeout time.Duration) (*Lock, error) {
+	path := filepath.Join(dir, lockFile)
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, pathError(path, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	waiting := false
+	for {
+		locked, err := tryLockFile(file)
+		if err != nil {
+			file.Close()
+			return nil, pathError(path, err)
+		}
+		if locked {
+			return &Lock{file: file}, nil
+		}
+
+		if !time.Now().Before(deadline) {
+			file.Close()
+			return nil, &LockTimeoutError{Path: path, Timeout: timeout}
+		}
+
+		if !waiting {
+			fmt.Fprintf(output, "Waiting for another tgswitch process to release the lock on %s (timeout %s)\n", dir, timeout)
+			waiting = true
+		}
+
+		select {
+		case <-ctx.Done():
+			file.Close()
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// Release : release the lock
+func (lock *Lock) Release() error {
+	if err := unlockFile(lock.file); err != nil {
+		lock.file.Close()
+		return err
+	}
+	return lock.file.Close()
+}
+
+// lockInstallLocation : lock the install location of terragrunt using the configured timeout
+func lockInstallLocation(ctx context.Context) (*Lock, error) {
+	return Terragrunt.lockInstallLocation(ctx)
+}
+
+// lockInstallLocation : lock the install location of the product using the configured timeout
+func (product *Product) lockInstallLocation(ctx context.Context) (*Lock, error) {
+	installLocation, err := product.InstallLocation()
+	if err != nil {
+		return nil, err
+	}
+
+	return AcquireLock(ctx, installLocation, lockTimeout)
+}
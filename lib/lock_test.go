@@ -0,0 +1,107 @@
+package lib_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+// TestAcquireLock : only one holder of the lock at a time
+func TestAcquireLock(t *testing.T) {
+
+	dir := t.TempDir()
+	var holders int32
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			lock, err := lib.AcquireLock(context.Background(), dir, 10*time.Second)
+			if err != nil {
+				t.Errorf("Unable to acquire lock %v [unexpected]", err)
+				return
+			}
+
+			if n := atomic.AddInt32(&holders, 1); n != 1 {
+				t.Errorf("Lock held by %d holders [unexpected]", n)
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&holders, -1)
+
+			if err := lock.Release(); err != nil {
+				t.Errorf("Unable to release lock %v [unexpected]", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestAcquireLockTimeout : waiting for a held lock stops at the timeout or when the context is done
+func TestAcquireLockTimeout(t *testing.T) {
+
+	dir := t.TempDir()
+	lock, err := lib.AcquireLock(context.Background(), dir, 0)
+	if err != nil {
+		t.Fatalf("Unable to acquire lock %v [unexpected]", err)
+	}
+	defer lock.Release()
+
+	_, err = lib.AcquireLock(context.Background(), dir, 200*time.Millisecond)
+	var timeout *lib.LockTimeoutError
+	if errors.As(err, &timeout) {
+		t.Logf("Returned lock timeout error %v [expected]", err)
+	} else {
+		t.Errorf("Expected a lock timeout error, got %v [unexpected]", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+	if _, err := lib.AcquireLock(ctx, dir, time.Minute); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected waiting to be cancelled, got %v [unexpected]", err)
+	}
+}
+
+// TestAddRecentConcurrent : concurrent updates of the recent file never leave it corrupted
+func TestAddRecentConcurrent(t *testing.T) {
+
+	t.Setenv("HOME", t.TempDir())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := lib.AddRecent(fmt.Sprintf("0.26.%d", i)); err != nil {
+				t.Errorf("Unable to add recent version %v [unexpected]", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	installLocation, err := lib.GetInstallLocation()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines, err := lib.ReadLines(filepath.Join(installLocation, "RECENT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 3 {
+		t.Errorf("Expected 3 recent versions, got %v [unexpected]", lines)
+	}
+	for _, line := range lines {
+		if !lib.ValidVersionFormat(line) {
+			t.Errorf("Recent file is corrupted %v [unexpected]", lines)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+// +build !windows
+
+package lib
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// tryLockFile : take an exclusive lock on the file without blocking. Returns false if another process holds it
+func tryLockFile(file *os.File) (bool, error) {
+	err := unix.Flock(int(file.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+	if err == unix.EWOULDBLOCK {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// unlockFile : release the lock on the file
+func unlockFile(file *os.File) error {
+	return unix.Flock(int(file.Fd()), unix.LOCK_UN)
+}
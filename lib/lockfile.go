@@ -0,0 +1,235 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	semver "github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl2/gohcl"
+	"github.com/hashicorp/hcl2/hclparse"
+	"github.com/hashicorp/hcl2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// LockFilename : the lock file pinning the versions resolved from the constraints of a project
+const LockFilename = ".tgswitch.lock.hcl"
+
+const lockFileHeader = `# This file is maintained automatically by "tgswitch lock".
+# Commit it so every machine installs the same versions. Run "tgswitch lock --upgrade" to update it.
+
+`
+
+// lockedDigests : the digests of the release assets per platform of the versions pinned by a lock file, by
+// installed version name. Installing a locked version fails when the release does not match them
+var lockedDigests = map[string]map[string]string{}
+
+// LockFile : the versions pinned for a project, by product name
+type LockFile struct {
+	Path     string
+	Products map[string]*LockedVersion
+}
+
+// LockedVersion : a version pinned by the lock file, the constraint it was resolved from and the sha256 digests
+// of its release assets per platform, such as linux_amd64
+type LockedVersion struct {
+	Version    string            `json:"version"`
+	Constraint string            `json:"constraint,omitempty"`
+	SHA256     map[string]string `json:"sha256,omitempty"`
+}
+
+// FindLockFile : the path of the nearest lock file in dir or its parent directories, up to stopAt. See FindInParentFolders
+func FindLockFile(dir string, stopAt string) (string, bool) {
+	return FindInParentFolders(dir, LockFilename, stopAt)
+}
+
+// ReadLockFile : read the lock file, a missing lock file pins nothing
+func ReadLockFile(lockPath string) (*LockFile, error) {
+	lock := &LockFile{Path: lockPath, Products: map[string]*LockedVersion{}}
+
+	content, err := ioutil.ReadFile(lockPath)
+	if os.IsNotExist(err) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, pathError(lockPath, err)
+	}
+
+	file, diags := hclparse.NewParser().ParseHCL(content, lockPath)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("unable to parse %s: %s", lockPath, diags.Error())
+	}
+	var blocks struct {
+		Products []struct {
+			Name       string            `hcl:"name,label"`
+			Version    string            `hcl:"version"`
+			Constraint string            `hcl:"constraint,optional"`
+			SHA256     map[string]string `hcl:"sha256,optional"`
+		} `hcl:"product,block"`
+	}
+	if diags := gohcl.DecodeBody(file.Body, nil, &blocks); diags.HasErrors() {
+		return nil, fmt.Errorf("unable to read %s: %s", lockPath, diags.Error())
+	}
+
+	for _, block := range blocks.Products {
+		if !ValidVersionFormat(block.Version) {
+			return nil, fmt.Errorf("invalid %s version %q in %s", block.Name, block.Version, lockPath)
+		}
+		lock.Products[block.Name] = &LockedVersion{Version: block.Version, Constraint: block.Constraint, SHA256: block.SHA256}
+	}
+	return lock, nil
+}
+
+// Write : write the lock file, replacing the previous lock file at once
+func (lock *LockFile) Write() error {
+	var content bytes.Buffer
+	content.WriteString(lockFileHeader)
+	for _, product := range Products {
+		locked, found := lock.Products[product.Name]
+		if !found {
+			continue
+		}
+		if content.Len() > len(lockFileHeader) {
+			content.WriteString("\n")
+		}
+
+		fmt.Fprintf(&content, "product %s {\n", hclString(product.Name))
+		fmt.Fprintf(&content, "version = %s\n", hclString(locked.Version))
+		if locked.Constraint != "" {
+			fmt.Fprintf(&content, "constraint = %s\n", hclString(locked.Constraint))
+		}
+		if len(locked.SHA256) > 0 {
+			platforms := make([]string, 0, len(locked.SHA256))
+			for platform := range locked.SHA256 {
+				platforms = append(platforms, platform)
+			}
+			sort.Strings(platforms)
+
+			content.WriteString("sha256 = {\n")
+			for _, platform := range platforms {
+				fmt.Fprintf(&content, "%s = %s\n", hclString(platform), hclString(locked.SHA256[platform]))
+			}
+			content.WriteString("}\n")
+		}
+		content.WriteString("}\n")
+	}
+
+	tmpFile := fmt.Sprintf("%s.%d.tmp", lock.Path, os.Getpid())
+	if err := ioutil.WriteFile(tmpFile, hclwrite.Format(content.Bytes()), 0644); err != nil {
+		return pathError(tmpFile, err)
+	}
+	if err := os.Rename(tmpFile, lock.Path); err != nil {
+		os.Remove(tmpFile)
+		return pathError(lock.Path, err)
+	}
+	return nil
+}
+
+// hclString : a string as a quoted HCL literal
+func hclString(value string) string {
+	return string(hclwrite.TokensForValue(cty.StringVal(value)).Bytes())
+}
+
+// LockVersion : pin a version of the product, with the digests of its release assets per platform read from the
+// signed checksum file of the release
+func (product *Product) LockVersion(ctx context.Context, version string, constraint string, mirrorURL string) (*LockedVersion, error) {
+	if !ValidVersionFormat(version) {
+		return nil, &InvalidVersionError{Version: version, Product: product.Name}
+	}
+
+	checksumURL := product.releaseURL(product.ChecksumURL, mirrorURL, version, runtime.GOOS, runtime.GOARCH)
+	signatureURL := product.releaseURL(product.signatureURL(), mirrorURL, version, runtime.GOOS, runtime.GOARCH)
+	checksums, err := getVerifiedChecksums(ctx, checksumURL, signatureURL)
+	if err != nil {
+		return nil, err
+	}
+
+	/* the asset names of every platform follow the asset url template */
+	assetName := regexp.QuoteMeta(path.Base(product.AssetURL))
+	assetName = strings.NewReplacer(
+		regexp.QuoteMeta("{version}"), regexp.QuoteMeta(version),
+		regexp.QuoteMeta("{os}"), `(?P<os>[a-z0-9]+)`,
+		regexp.QuoteMeta("{arch}"), `(?P<arch>[a-z0-9]+)`,
+	).Replace(assetName)
+	assetPattern := regexp.MustCompile(`^` + assetName + `(?:\.exe)?$`)
+
+	locked := &LockedVersion{Version: version, Constraint: constraint, SHA256: map[string]string{}}
+	for fileName, digest := range checksums {
+		if match := assetPattern.FindStringSubmatch(fileName); match != nil {
+			locked.SHA256[match[assetPattern.SubexpIndex("os")]+"_"+match[assetPattern.SubexpIndex("arch")]] = digest
+		}
+	}
+	if len(locked.SHA256) == 0 {
+		return nil, fmt.Errorf("no %s release asset listed in %s", product.Name, checksumURL)
+	}
+	return locked, nil
+}
+
+// ApplyLock : pin the version resolved from the version sources to the version of the nearest lock file, up to
+// stopAt. A version from the command line is not pinned. Fails when the locked version no longer satisfies the
+// resolved version or constraint, kept in Required. The lock file is added to the trace, and installs of the locked version are
+// verified against its digests
+func (product *Product) ApplyLock(resolution *Resolution, dir string, stopAt string) error {
+	if resolution.Version == "" || resolution.Source == CommandLineSource {
+		return nil
+	}
+	lockPath, found := FindLockFile(dir, stopAt)
+	if !found {
+		return nil
+	}
+	lock, err := ReadLockFile(lockPath)
+	if err != nil {
+		return err
+	}
+	locked, found := lock.Products[product.Name]
+	if !found {
+		return nil
+	}
+
+	if !locked.Satisfies(resolution.Version) {
+		return fmt.Errorf("%s version %s locked in %s does not satisfy %q from %s, run tgswitch lock --upgrade", product.Name, locked.Version, lockPath, resolution.Version, resolution.Source)
+	}
+
+	resolution.Required = resolution.Version
+	resolution.Version = locked.Version
+	resolution.Lock = lockPath
+	resolution.Trace = append(resolution.Trace, TraceEntry{Source: LockFilename, Location: lockPath, Version: locked.Version, Status: SourceLocked})
+	lockedDigests[product.VersionPrefix+locked.Version] = locked.SHA256
+	return nil
+}
+
+// Satisfies : check the locked version satisfies a version or a constraint
+func (locked *LockedVersion) Satisfies(versionOrConstraint string) bool {
+	if ValidVersionFormat(versionOrConstraint) {
+		return versionOrConstraint == locked.Version
+	}
+	constraint, err := semver.NewConstraint(versionOrConstraint)
+	if err != nil {
+		return false
+	}
+	version, err := semver.NewVersion(locked.Version)
+	return err == nil && constraint.Check(version)
+}
+
+// LockFilePath : where the lock file of a resolution is written: the nearest existing lock file, or else the
+// directory of the file the version was read from when FindLockFile searches it, or else dir. A file outside of
+// the project, such as the .tgswitch.toml of the home directory, does not get the lock file
+func LockFilePath(resolution *Resolution, dir string, stopAt string) string {
+	if lockPath, found := FindLockFile(dir, stopAt); found {
+		return lockPath
+	}
+	if info, err := os.Stat(resolution.Location); err == nil && info.Mode().IsRegular() {
+		if locationDir, err := filepath.Abs(filepath.Dir(resolution.Location)); err == nil && VersionExist(locationDir, parentFolders(dir, stopAt)) {
+			return filepath.Join(locationDir, LockFilename)
+		}
+	}
+	return filepath.Join(dir, LockFilename)
+}
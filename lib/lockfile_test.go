@@ -0,0 +1,102 @@
+package lib_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+// TestLockFile : a locked version is written and read back, pins the resolved constraint and the digest of its release
+func TestLockFile(t *testing.T) {
+
+	newTestInstallLocation(t)
+	key, armored := newTestKey(t, "terraform release key")
+	verifier, err := lib.NewSignatureVerifier([]string{armored}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lib.SetSignatureVerifier(verifier)
+	t.Cleanup(func() { lib.SetSignatureVerifier(nil) })
+
+	mirror := newTestTerraformMirror(t, "1.5.7", func(data []byte) []byte { return signTestData(t, key, data) })
+	defer mirror.Close()
+	mirrorURL := mirror.URL + "/terraform/"
+
+	locked, err := lib.Terraform.LockVersion(context.Background(), "1.5.7", "~> 1.5.0", mirrorURL)
+	platform := runtime.GOOS + "_" + runtime.GOARCH
+	if err != nil || len(locked.SHA256[platform]) != 64 {
+		t.Fatalf("Expected the digest of %s, got %v %v [unexpected]", platform, locked, err)
+	}
+	t.Logf("Locked digest %s of %s [expected]", locked.SHA256[platform], platform)
+
+	/* the lock file is read back as written */
+	project := t.TempDir()
+	dir := filepath.Join(project, "live")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	lock := &lib.LockFile{Path: filepath.Join(project, lib.LockFilename), Products: map[string]*lib.LockedVersion{"terraform": locked}}
+	if err := lock.Write(); err != nil {
+		t.Fatalf("Unable to write the lock file %v [unexpected]", err)
+	}
+	read, err := lib.ReadLockFile(lock.Path)
+	if err != nil || !reflect.DeepEqual(read.Products, lock.Products) {
+		t.Errorf("Expected %v, got %v %v [unexpected]", lock.Products, read.Products, err)
+	}
+
+	resolve := func(version string, source string) (*lib.Resolution, error) {
+		resolution := &lib.Resolution{Version: version, Source: source}
+		return resolution, lib.Terraform.ApplyLock(resolution, dir, project)
+	}
+
+	if resolution, err := resolve("~> 1.5.0", lib.TerraformVersionFilename); err != nil || resolution.Version != "1.5.7" || resolution.Lock != lock.Path {
+		t.Errorf("Expected the locked 1.5.7, got %+v %v [unexpected]", resolution, err)
+	} else {
+		t.Logf("Constraint pinned to %s by %s [expected]", resolution.Version, resolution.Lock)
+	}
+	if resolution, err := resolve("1.6.0", lib.CommandLineSource); err != nil || resolution.Version != "1.6.0" {
+		t.Errorf("Expected the command line version to be used as is, got %+v %v [unexpected]", resolution, err)
+	}
+	if _, err := resolve(">= 1.6", lib.TerraformVersionFilename); err == nil {
+		t.Error("Lock no longer satisfying the constraint should be reported [unexpected]")
+	} else {
+		t.Logf("Returned %v [expected]", err)
+	}
+	if lib.LockFilePath(&lib.Resolution{}, dir, project) != lock.Path {
+		t.Error("Expected the nearest lock file to be updated [unexpected]")
+	}
+
+	/* a release that changed since it was locked is not installed */
+	digest := locked.SHA256[platform]
+	locked.SHA256[platform] = strings.Repeat("0", 64)
+	if err := lock.Write(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resolve("~> 1.5.0", lib.TerraformVersionFilename); err != nil {
+		t.Fatal(err)
+	}
+	var checksumErr *lib.ChecksumError
+	if _, err := lib.Terraform.InstallVersion(context.Background(), "1.5.7", mirrorURL); !errors.As(err, &checksumErr) {
+		t.Errorf("Expected a checksum error, got %v [unexpected]", err)
+	} else {
+		t.Logf("Refused the release not matching the lock file %v [expected]", err)
+	}
+
+	locked.SHA256[platform] = digest
+	if err := lock.Write(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resolve("~> 1.5.0", lib.TerraformVersionFilename); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lib.Terraform.InstallVersion(context.Background(), "1.5.7", mirrorURL); err != nil {
+		t.Errorf("Expected the release matching the lock file to be installed, got %v [unexpected]", err)
+	}
+}
@@ -0,0 +1,45 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+)
+
+var offline = false
+
+// SetOffline : with offline the version list is never downloaded, versions and constraints are resolved
+// against the installed versions only
+func SetOffline(enabled bool) {
+	offline = enabled
+}
+
+// availableReleases : the releases of the version list, or the installed versions when offline. When the version
+// list cannot be downloaded and is not cached, the installed versions are used as well unless the list is refreshed.
+// installedOnly reports the installed versions were used
+func (product *Product) availableReleases(listURL string) (releases []Release, installedOnly bool, err error) {
+	if offline {
+		installed, err := product.InstalledVersions()
+		return versionReleases(installed), true, err
+	}
+
+	releases, err = product.remoteReleases(listURL)
+	var downloadErr *DownloadError
+	if errors.As(err, &downloadErr) && !refreshVersionList {
+		if installed, installedErr := product.InstalledVersions(); installedErr == nil && len(installed) > 0 {
+			fmt.Fprintf(output, "[Warning] : %v, using the installed versions\n", err)
+			return versionReleases(installed), true, nil
+		}
+	}
+	return releases, false, err
+}
+
+// notFoundError : report a version of the product was not found, among the installed versions rather than the
+// version list when installedOnly
+func (product *Product) notFoundError(err error, installedOnly bool) error {
+	var notFound *VersionNotFoundError
+	if errors.As(err, &notFound) {
+		notFound.Product = product.Name
+		notFound.InstalledOnly = notFound.InstalledOnly || installedOnly
+	}
+	return err
+}
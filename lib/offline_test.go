@@ -0,0 +1,54 @@
+package lib_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+// TestOffline : offline, or when the version list cannot be downloaded, versions are resolved against the installed versions
+func TestOffline(t *testing.T) {
+
+	newTestInstallLocation(t, "0.38.0", "0.37.1", "0.36.0")
+	t.Cleanup(func() { lib.SetOffline(false) })
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	lib.SetOffline(true)
+	if version, err := lib.Terragrunt.ResolveVersion("~> 0.37.0", server.URL); err != nil || version != "0.37.1" {
+		t.Errorf("Expected 0.37.1, got %s %v [unexpected]", version, err)
+	} else {
+		t.Logf("Resolved the constraint offline to %s [expected]", version)
+	}
+	if version, err := lib.Terragrunt.LatestVersion(server.URL); err != nil || version != "0.38.0" {
+		t.Errorf("Expected 0.38.0, got %s %v [unexpected]", version, err)
+	}
+	if requests != 0 {
+		t.Errorf("Expected no request offline, got %d [unexpected]", requests)
+	}
+
+	var notFound *lib.VersionNotFoundError
+	if _, err := lib.Terragrunt.ResolveVersion(">= 1.0", server.URL); !errors.As(err, &notFound) || !notFound.InstalledOnly {
+		t.Errorf("Expected a version not found error among the installed versions, got %v [unexpected]", err)
+	} else {
+		t.Logf("Returned %v [expected]", err)
+	}
+
+	lib.SetOffline(false)
+	if version, err := lib.Terragrunt.ResolveVersion("< 0.38", server.URL); err != nil || version != "0.37.1" {
+		t.Errorf("Expected the installed versions when the version list is unavailable, got %s %v [unexpected]", version, err)
+	} else {
+		t.Logf("Fell back to the installed version %s [expected]", version)
+	}
+	if requests == 0 {
+		t.Error("Expected the version list to be requested online [unexpected]")
+	}
+}
@@ -0,0 +1,19 @@
+package lib
+
+import (
+	"io"
+	"os"
+)
+
+// output : where progress messages are printed, stdout unless the caller needs stdout for results
+var output io.Writer = os.Stdout
+
+// SetOutput : print progress messages to the writer, such as stderr when stdout is used for JSON results
+func SetOutput(writer io.Writer) {
+	output = writer
+}
+
+// Output : the writer progress messages are printed to
+func Output() io.Writer {
+	return output
+}
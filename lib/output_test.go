@@ -0,0 +1,25 @@
+package lib_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+// TestSetOutput : progress messages are printed to the configured writer
+func TestSetOutput(t *testing.T) {
+
+	var messages bytes.Buffer
+	lib.SetOutput(&messages)
+	defer lib.SetOutput(os.Stdout)
+
+	lib.PrintInvalidTGVersion()
+
+	if messages.Len() > 0 {
+		t.Logf("Message printed to writer %q [expected]", messages.String())
+	} else {
+		t.Error("Message not printed to writer [unexpected]")
+	}
+}
@@ -0,0 +1,348 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+// formats of the version list of a product
+const (
+	VersionListJSON     = "json"     // a JSON document listing the versions: {"Versions": ["0.38.0", ...]}
+	VersionListIndex    = "index"    // the HTML index of a HashiCorp style releases mirror, with a link per version
+	VersionListOpenTofu = "opentofu" // the release API of OpenTofu: {"versions": [{"id": "1.6.0"}, ...]}
+	VersionListGitHub   = "github"   // the GitHub Releases API: [{"tag_name": "v0.38.0", ...}, ...], paginated with Link headers
+)
+
+// Release : a version of a version list. Only the GitHub Releases API tells the publication date and the asset names
+type Release struct {
+	Version    string    `json:"version"`
+	Prerelease bool      `json:"prerelease,omitempty"`
+	Published  time.Time `json:"published"`
+	Assets     []string  `json:"assets,omitempty"`
+}
+
+// Product : a binary tgswitch installs and switches between versions of, such as terragrunt or terraform.
+// URL templates may use {mirror}, {version}, {os} and {arch}
+type Product struct {
+	Name                string   // name of the binary, used in messages and for the bin link
+	VersionPrefix       string   // installed versions are named <VersionPrefix><version>
+	InstallPath         string   // directory in the home directory the versions are installed in
+	DefaultBin          string   // default bin path linked to the version in use
+	DefaultMirror       string   // default releases mirror the {mirror} of the url templates is replaced with
+	DefaultVersionURL   string   // default url of the version list
+	AssetURL            string   // url template of the release asset, the last path element is looked up in the checksum file
+	ChecksumURL         string   // url template of the SHA256SUMS style checksum file of a release
+	SignatureURL        string   // url template of the detached signature of the checksum file, <ChecksumURL>.sig when empty
+	Archive             string   // empty when the release asset is the binary, "zip" when the binary is in a zip archive
+	VersionList         string   // format of the version list: VersionListJSON, VersionListIndex, VersionListOpenTofu or VersionListGitHub
+	DarwinArm64Since    string   // first version built for darwin arm64, the amd64 build of older versions is installed
+	VersionFiles        []string // files containing only the version, from highest to lowest precedence
+	ConstraintAttribute string   // attribute of terragrunt.hcl with the version constraint
+	VersionEnv          string   // environment variable with the version
+}
+
+// Terragrunt : terragrunt releases published on github
+var Terragrunt = &Product{
+	Name:                "terragrunt",
+	VersionPrefix:       "terragrunt_",
+	InstallPath:         ".terragrunt.versions",
+	DefaultBin:          "/usr/local/bin/terragrunt",
+	DefaultMirror:       "https://github.com/gruntwork-io/terragrunt/releases/download/",
+	DefaultVersionURL:   "https://api.github.com/repos/gruntwork-io/terragrunt/releases?per_page=100",
+	AssetURL:            "{mirror}/v{version}/terragrunt_{os}_{arch}",
+	ChecksumURL:         "{mirror}/v{version}/SHA256SUMS",
+	VersionList:         VersionListGitHub,
+	DarwinArm64Since:    "0.28.12",
+	VersionFiles:        []string{RCFilename, TGVersionFilename},
+	ConstraintAttribute: versionConstraintAttribute,
+	VersionEnv:          VersionEnv,
+}
+
+// Terraform : terraform releases published on a HashiCorp style releases mirror, such as https://releases.hashicorp.com/terraform
+var Terraform = &Product{
+	Name:                "terraform",
+	VersionPrefix:       "terraform_",
+	InstallPath:         ".terraform.versions",
+	DefaultBin:          "/usr/local/bin/terraform",
+	DefaultMirror:       "https://releases.hashicorp.com/terraform",
+	DefaultVersionURL:   "https://releases.hashicorp.com/terraform", //the index of the mirror lists the versions
+	AssetURL:            "{mirror}/{version}/terraform_{version}_{os}_{arch}.zip",
+	ChecksumURL:         "{mirror}/{version}/terraform_{version}_SHA256SUMS",
+	Archive:             "zip",
+	VersionList:         VersionListIndex,
+	DarwinArm64Since:    "1.0.2",
+	VersionFiles:        []string{TerraformVersionFilename},
+	ConstraintAttribute: terraformConstraintAttribute,
+	VersionEnv:          TerraformVersionEnv,
+}
+
+// OpenTofu : OpenTofu releases published on github, listed by the release API of get.opentofu.org.
+// Terragrunt runs tofu like terraform, so the version constraint is read from terraform_version_constraint
+var OpenTofu = &Product{
+	Name:                "tofu",
+	VersionPrefix:       "tofu_",
+	InstallPath:         ".tofu.versions",
+	DefaultBin:          "/usr/local/bin/tofu",
+	DefaultMirror:       "https://github.com/opentofu/opentofu/releases/download",
+	DefaultVersionURL:   "https://get.opentofu.org/tofu/api.json",
+	AssetURL:            "{mirror}/v{version}/tofu_{version}_{os}_{arch}.zip",
+	ChecksumURL:         "{mirror}/v{version}/tofu_{version}_SHA256SUMS",
+	SignatureURL:        "{mirror}/v{version}/tofu_{version}_SHA256SUMS.gpgsig",
+	Archive:             "zip",
+	VersionList:         VersionListOpenTofu,
+	DarwinArm64Since:    "1.6.0",
+	VersionFiles:        []string{OpenTofuVersionFilename},
+	ConstraintAttribute: terraformConstraintAttribute,
+	VersionEnv:          OpenTofuVersionEnv,
+}
+
+// Products : the products tgswitch manages
+var Products = []*Product{Terragrunt, Terraform, OpenTofu}
+
+// GetProduct : look up a product by the name of its binary
+func GetProduct(name string) (*Product, error) {
+	for _, product := range Products {
+		if product.Name == name {
+			return product, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown product %q, expected one of: %s", name, strings.Join(ProductNames(), ", "))
+}
+
+// ProductNames : the names of the products tgswitch manages
+func ProductNames() []string {
+	names := make([]string, 0, len(Products))
+	for _, product := range Products {
+		names = append(names, product.Name)
+	}
+	return names
+}
+
+// releaseURL : expand a url template for a version
+func (product *Product) releaseURL(template string, mirrorURL string, version string, goos string, goarch string) string {
+	return strings.NewReplacer(
+		"{mirror}", strings.TrimSuffix(mirrorURL, "/"),
+		"{version}", version,
+		"{os}", goos,
+		"{arch}", goarch,
+	).Replace(template)
+}
+
+// releaseArch : the architecture of the build of the version installed on this platform. darwin arm64 builds are
+// published from DarwinArm64Since, older versions run the amd64 build
+func (product *Product) releaseArch(releaseVersion string) string {
+	requested, errRequested := version.NewVersion(releaseVersion)
+	firstArm64, errFirst := version.NewVersion(product.DarwinArm64Since)
+	if runtime.GOOS == "darwin" && runtime.GOARCH == "arm64" && errRequested == nil && errFirst == nil && requested.LessThan(firstArm64) {
+		return "amd64"
+	}
+	return runtime.GOARCH
+}
+
+// hasPlatformAsset : check the release has a build for this platform. Releases whose assets the version list
+// does not tell are assumed to have one
+func (product *Product) hasPlatformAsset(release Release) bool {
+	if len(release.Assets) == 0 {
+		return true
+	}
+	asset := path.Base(product.releaseURL(product.AssetURL, "", release.Version, runtime.GOOS, product.releaseArch(release.Version)))
+	for _, name := range release.Assets {
+		if name == asset {
+			return true
+		}
+	}
+	return false
+}
+
+// ListVersions : get the versions of the product available for download from the version list. Pre-releases
+// such as betas and release candidates are only listed with preRelease
+func (product *Product) ListVersions(versionURL string, preRelease bool) ([]string, error) {
+	list, _, err := product.listVersions(versionURL, preRelease)
+	return list, err
+}
+
+// ListReleases : get the releases of the product available for download from the version list, with their
+// publication date and asset names when the version list tells them. Releases without a build for this platform
+// are skipped. Pre-releases are only listed with preRelease
+func (product *Product) ListReleases(versionURL string, preRelease bool) ([]Release, error) {
+	list, _, err := product.listReleases(versionURL, preRelease)
+	return list, err
+}
+
+// listVersions : get the versions available for download, installedOnly reports only the installed versions were
+// available, offline or without network
+func (product *Product) listVersions(versionURL string, preRelease bool) ([]string, bool, error) {
+	releases, installedOnly, err := product.listReleases(versionURL, preRelease)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var list []string
+	for _, release := range releases {
+		list = append(list, release.Version)
+	}
+	return list, installedOnly, nil
+}
+
+// listReleases : get the releases available for download, see listVersions
+func (product *Product) listReleases(versionURL string, preRelease bool) ([]Release, bool, error) {
+	listURL := versionURL
+	if product.VersionList == VersionListIndex {
+		listURL = strings.TrimSuffix(versionURL, "/") + "/" //the index is the directory listing of the mirror
+	}
+	releases, installedOnly, err := product.availableReleases(listURL)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var list []Release
+	for _, release := range releases {
+		if ValidVersionFormat(release.Version) && (preRelease || !release.Prerelease) && product.hasPlatformAsset(release) {
+			list = append(list, release)
+		}
+	}
+	if len(list) == 0 && !installedOnly {
+		fmt.Fprintf(output, "Cannot get list from mirror: %s\n", versionURL)
+	}
+	return list, installedOnly, nil
+}
+
+// VersionURL : the default version list of the product when installing from the mirror. The index of a
+// HashiCorp style mirror is its version list
+func (product *Product) VersionURL(mirrorURL string) string {
+	if product.VersionList == VersionListIndex {
+		return mirrorURL
+	}
+	return product.DefaultVersionURL
+}
+
+// LatestVersion : get the newest stable version of the product available for download
+func (product *Product) LatestVersion(versionURL string) (string, error) {
+	versions, installedOnly, err := product.listVersions(versionURL, false)
+	if err != nil {
+		return "", err
+	}
+
+	sorted := sortedVersions(versions)
+	if len(sorted) == 0 {
+		return "", &VersionNotFoundError{Version: "latest", Product: product.Name, InstalledOnly: installedOnly}
+	}
+	return sorted[0].Original(), nil
+}
+
+// LatestImplicitVersion : get the newest version of the product for a minor version such as 1.6. With preRelease
+// the newest pre-release of the minor version, otherwise the newest stable patch release
+func (product *Product) LatestImplicitVersion(versionURL string, preRelease bool, minorVersion string) (string, error) {
+	versions, installedOnly, err := product.listVersions(versionURL, preRelease)
+	if err != nil {
+		return "", err
+	}
+
+	if preRelease {
+		for _, element := range sortedVersions(versions) {
+			if strings.HasPrefix(element.Original(), minorVersion+".") && element.Prerelease() != "" {
+				return element.Original(), nil
+			}
+		}
+		return "", &VersionNotFoundError{Version: minorVersion, Product: product.Name, InstalledOnly: installedOnly}
+	}
+
+	constraint := fmt.Sprintf("~> %v", minorVersion)
+	version, err := SemVerParser(&constraint, versions)
+	return version, product.notFoundError(err, installedOnly)
+}
+
+// ResolveVersion : get the version available for download matching a version, or the version matching a constraint
+// according to the resolution strategy
+func (product *Product) ResolveVersion(versionOrConstraint string, versionURL string) (string, error) {
+	if !ValidVersionFormat(versionOrConstraint) {
+		return product.resolveConstraint(versionOrConstraint, versionURL, true)
+	}
+
+	versions, installedOnly, err := product.listVersions(versionURL, true)
+	if err != nil {
+		return "", err
+	}
+	if !VersionExist(versionOrConstraint, versions) {
+		return "", &VersionNotFoundError{Version: versionOrConstraint, Product: product.Name, InstalledOnly: installedOnly}
+	}
+	return versionOrConstraint, nil
+}
+
+// signatureURL : url template of the detached signature of the checksum file
+func (product *Product) signatureURL() string {
+	if product.SignatureURL != "" {
+		return product.SignatureURL
+	}
+	return product.ChecksumURL + ".sig"
+}
+
+// parseVersionList : get the releases of a version list, or of a page of it, in the format of the product
+func (product *Product) parseVersionList(body []byte) ([]Release, error) {
+	switch product.VersionList {
+	case VersionListJSON:
+		var list ListVersion
+		if err := json.Unmarshal(body, &list); err != nil {
+			return nil, err
+		}
+		return versionReleases(list.Versions), nil
+	case VersionListOpenTofu:
+		versions, err := parseOpenTofuVersions(body)
+		return versionReleases(versions), err
+	case VersionListGitHub:
+		return parseGitHubReleases(body)
+	default:
+		return versionReleases(parseIndexVersions(body)), nil
+	}
+}
+
+// versionReleases : the releases of a list of versions, where the pre-releases are the versions with a suffix
+func versionReleases(versions []string) []Release {
+	releases := make([]Release, 0, len(versions))
+	for _, version := range versions {
+		releases = append(releases, Release{Version: version, Prerelease: strings.Contains(version, "-")})
+	}
+	return releases
+}
+
+// indexVersionLink : a link to the directory of a release in the HTML index of a releases mirror. Ex: href="/terraform/1.5.7/"
+var indexVersionLink = regexp.MustCompile(`href="[^"]*?/?(\d+\.\d+\.\d+(?:-[a-zA-Z]+\d*)?)/?"`)
+
+// parseIndexVersions : get the versions linked from the HTML index of a releases mirror
+func parseIndexVersions(body []byte) []string {
+	var versions []string
+	for _, match := range indexVersionLink.FindAllStringSubmatch(string(body), -1) {
+		if !VersionExist(match[1], versions) {
+			versions = append(versions, match[1])
+		}
+	}
+	return versions
+}
+
+// openTofuVersionList : the version list of the OpenTofu release API
+type openTofuVersionList struct {
+	Versions []struct {
+		ID string `json:"id"`
+	} `json:"versions"`
+}
+
+// parseOpenTofuVersions : get the versions listed by the OpenTofu release API
+func parseOpenTofuVersions(body []byte) ([]string, error) {
+	var list openTofuVersionList
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, err
+	}
+
+	versions := make([]string, 0, len(list.Versions))
+	for _, version := range list.Versions {
+		versions = append(versions, strings.TrimPrefix(version.ID, "v"))
+	}
+	return versions, nil
+}
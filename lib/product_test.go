@@ -0,0 +1,258 @@
+package lib_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+const testIndex = `<html><body><ul>
+<li><a href="../">../</a></li>
+<li><a href="/terraform/1.6.0-rc1/">terraform_1.6.0-rc1</a></li>
+<li><a href="/terraform/1.5.7/">terraform_1.5.7</a></li>
+<li><a href="/terraform/1.5.6/">terraform_1.5.6</a></li>
+<li><a href="/terraform/0.13.7/">terraform_0.13.7</a></li>
+</ul></body></html>`
+
+// newTestTerraformMirror : serve the index, a zipped terraform binary and its signed checksum file like releases.hashicorp.com
+func newTestTerraformMirror(t *testing.T, version string, signature func([]byte) []byte) *httptest.Server {
+
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	binary, err := writer.Create(lib.ConvertExecutableExt("terraform"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary.Write([]byte(testBinaryContent))
+	writer.Close()
+
+	assetName := fmt.Sprintf("terraform_%s_%s_%s.zip", version, runtime.GOOS, runtime.GOARCH)
+	digest := sha256.Sum256(archive.Bytes())
+	checksums := []byte(hex.EncodeToString(digest[:]) + "  " + assetName + "\n")
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/terraform/":
+			w.Write([]byte(testIndex))
+		case "/terraform/" + version + "/" + assetName:
+			w.Write(archive.Bytes())
+		case "/terraform/" + version + "/terraform_" + version + "_SHA256SUMS":
+			w.Write(checksums)
+		case "/terraform/" + version + "/terraform_" + version + "_SHA256SUMS.sig":
+			w.Write(signature(checksums))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// TestProductListVersions : versions are read from the HTML index of a releases mirror
+func TestProductListVersions(t *testing.T) {
+
+	newTestInstallLocation(t)
+	mirror := newTestTerraformMirror(t, "1.5.7", func(data []byte) []byte { return nil })
+	defer mirror.Close()
+
+	stable, err := lib.Terraform.ListVersions(mirror.URL+"/terraform/", false)
+	if err != nil || len(stable) != 3 {
+		t.Errorf("Expected 3 stable versions, got %v %v [unexpected]", stable, err)
+	}
+
+	all, err := lib.Terraform.ListVersions(mirror.URL+"/terraform/", true)
+	if err != nil || len(all) != 4 {
+		t.Errorf("Expected 4 versions, got %v %v [unexpected]", all, err)
+	}
+
+	tests := map[string]string{
+		">= 0.13, < 0.14": "0.13.7",
+		"~> 1.5.0":        "1.5.7",
+		"1.5.6":           "1.5.6",
+	}
+	for constraint, expected := range tests {
+		version, err := lib.Terraform.ResolveVersion(constraint, mirror.URL+"/terraform")
+		if err != nil || version != expected {
+			t.Errorf("Expected %s for %q, got %s %v [unexpected]", expected, constraint, version, err)
+		} else {
+			t.Logf("Resolved %q to %s [expected]", constraint, version)
+		}
+	}
+
+	if _, err := lib.Terraform.ResolveVersion("1.4.0", mirror.URL+"/terraform"); err == nil {
+		t.Error("Missing version should not be resolved [unexpected]")
+	}
+}
+
+// TestProductInstallZip : the zip archive is verified, the binary extracted to its own install location
+func TestProductInstallZip(t *testing.T) {
+
+	newTestInstallLocation(t)
+	key, armored := newTestKey(t, "terraform release key")
+	verifier, err := lib.NewSignatureVerifier([]string{armored}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lib.SetSignatureVerifier(verifier)
+	t.Cleanup(func() { lib.SetSignatureVerifier(nil) })
+
+	mirror := newTestTerraformMirror(t, "1.5.7", func(data []byte) []byte { return signTestData(t, key, data) })
+	defer mirror.Close()
+
+	installed, err := lib.Terraform.InstallVersion(context.Background(), "1.5.7", mirror.URL+"/terraform/")
+	if err != nil {
+		t.Fatalf("Unable to install terraform %v [unexpected]", err)
+	}
+
+	expected := filepath.Join(os.Getenv("HOME"), ".terraform.versions", lib.ConvertExecutableExt("terraform_1.5.7"))
+	content, err := os.ReadFile(installed)
+	if installed != expected || err != nil || string(content) != testBinaryContent {
+		t.Errorf("Expected the extracted binary at %s, got %s %v [unexpected]", expected, installed, err)
+	} else {
+		t.Logf("Installed terraform at %s [expected]", installed)
+	}
+
+	/* only the versioned binary and the recorded checksum are left, no archive */
+	entries, _ := os.ReadDir(filepath.Dir(expected))
+	for _, entry := range entries {
+		if entry.Name() != filepath.Base(expected) && entry.Name() != "SHA256SUMS" && entry.Name() != ".lock" {
+			t.Errorf("Unexpected file %s left in the install location [unexpected]", entry.Name())
+		}
+	}
+
+	if _, err := lib.Terraform.InstallVersion(context.Background(), "1.5.6", mirror.URL+"/terraform/"); err == nil {
+		t.Error("Missing release should not be installed [unexpected]")
+	}
+}
+
+const testOpenTofuAPI = `{"versions": [
+  {"id": "1.7.0-alpha1", "files": ["tofu_1.7.0-alpha1_linux_amd64.zip"]},
+  {"id": "1.6.2", "files": ["tofu_1.6.2_linux_amd64.zip"]},
+  {"id": "1.6.1", "files": ["tofu_1.6.1_linux_amd64.zip"]}
+]}`
+
+// newTestOpenTofuMirror : serve the release API, a zipped tofu binary and its checksum file signed with a .gpgsig like OpenTofu
+func newTestOpenTofuMirror(t *testing.T, version string, signature func([]byte) []byte) *httptest.Server {
+
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	binary, err := writer.Create(lib.ConvertExecutableExt("tofu"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary.Write([]byte(testBinaryContent))
+	writer.Close()
+
+	assetName := fmt.Sprintf("tofu_%s_%s_%s.zip", version, runtime.GOOS, runtime.GOARCH)
+	digest := sha256.Sum256(archive.Bytes())
+	checksums := []byte(hex.EncodeToString(digest[:]) + "  " + assetName + "\n")
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tofu/api.json":
+			w.Write([]byte(testOpenTofuAPI))
+		case "/download/v" + version + "/" + assetName:
+			w.Write(archive.Bytes())
+		case "/download/v" + version + "/tofu_" + version + "_SHA256SUMS":
+			w.Write(checksums)
+		case "/download/v" + version + "/tofu_" + version + "_SHA256SUMS.gpgsig":
+			w.Write(signature(checksums))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// TestOpenTofuListVersions : versions are read from the OpenTofu release API
+func TestOpenTofuListVersions(t *testing.T) {
+
+	newTestInstallLocation(t)
+	mirror := newTestOpenTofuMirror(t, "1.6.2", func(data []byte) []byte { return nil })
+	defer mirror.Close()
+	apiURL := mirror.URL + "/tofu/api.json"
+
+	all, err := lib.OpenTofu.ListVersions(apiURL, true)
+	if err != nil || len(all) != 3 {
+		t.Errorf("Expected 3 versions, got %v %v [unexpected]", all, err)
+	}
+
+	if latest, err := lib.OpenTofu.LatestVersion(apiURL); err != nil || latest != "1.6.2" {
+		t.Errorf("Expected latest 1.6.2, got %s %v [unexpected]", latest, err)
+	} else {
+		t.Logf("Latest tofu version %s [expected]", latest)
+	}
+
+	if pre, err := lib.OpenTofu.LatestImplicitVersion(apiURL, true, "1.7"); err != nil || pre != "1.7.0-alpha1" {
+		t.Errorf("Expected pre-release 1.7.0-alpha1, got %s %v [unexpected]", pre, err)
+	}
+
+	if version, err := lib.OpenTofu.ResolveVersion("~> 1.6.0, < 1.6.2", apiURL); err != nil || version != "1.6.1" {
+		t.Errorf("Expected 1.6.1, got %s %v [unexpected]", version, err)
+	}
+}
+
+// TestOpenTofuInstall : the checksum file is verified with the signature named by the SignatureURL template
+func TestOpenTofuInstall(t *testing.T) {
+
+	newTestInstallLocation(t)
+	key, armored := newTestKey(t, "opentofu release key")
+	verifier, err := lib.NewSignatureVerifier([]string{armored}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lib.SetSignatureVerifier(verifier)
+	t.Cleanup(func() { lib.SetSignatureVerifier(nil) })
+
+	mirror := newTestOpenTofuMirror(t, "1.6.2", func(data []byte) []byte { return signTestData(t, key, data) })
+	defer mirror.Close()
+
+	installed, err := lib.OpenTofu.InstallVersion(context.Background(), "1.6.2", mirror.URL+"/download")
+	if err != nil {
+		t.Fatalf("Unable to install tofu %v [unexpected]", err)
+	}
+
+	expected := filepath.Join(os.Getenv("HOME"), ".tofu.versions", lib.ConvertExecutableExt("tofu_1.6.2"))
+	if installed != expected {
+		t.Errorf("Expected tofu at %s, got %s [unexpected]", expected, installed)
+	} else {
+		t.Logf("Installed tofu at %s [expected]", installed)
+	}
+
+	/* every product has its own install location */
+	if versions, err := lib.OpenTofu.InstalledVersions(); err != nil || len(versions) != 1 || versions[0] != "1.6.2" {
+		t.Errorf("Expected tofu 1.6.2 installed, got %v %v [unexpected]", versions, err)
+	}
+	if versions, err := lib.Terragrunt.InstalledVersions(); err != nil || len(versions) != 0 {
+		t.Errorf("Expected no terragrunt versions, got %v %v [unexpected]", versions, err)
+	}
+
+	removed, err := lib.OpenTofu.UninstallVersions(context.Background(), "1.6.2", filepath.Join(t.TempDir(), "tofu"), false, false)
+	if err != nil || len(removed) != 1 || lib.CheckFileExist(expected) {
+		t.Errorf("Expected tofu 1.6.2 to be uninstalled, got %v %v [unexpected]", removed, err)
+	}
+}
+
+// TestGetProduct : products are looked up by the name of their binary
+func TestGetProduct(t *testing.T) {
+
+	for _, name := range []string{"terragrunt", "terraform", "tofu"} {
+		if product, err := lib.GetProduct(name); err != nil || product.Name != name {
+			t.Errorf("Expected product %s, got %v %v [unexpected]", name, product, err)
+		}
+	}
+
+	if _, err := lib.GetProduct("pulumi"); err == nil {
+		t.Error("Unknown product should be reported [unexpected]")
+	} else {
+		t.Logf("Unknown product reported %v [expected]", err)
+	}
+}
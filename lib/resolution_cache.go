@@ -0,0 +1,123 @@
+package lib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const resolutionCacheFile = ".resolutions.json" //versions resolved per directory by the shell hook
+
+// ResolutionCache : the exact versions resolved per directory. An entry stays valid while the files the version
+// sources read and the directories from the directory up to the filesystem root are unchanged, so adding,
+// editing or removing a version file invalidates it
+type ResolutionCache struct {
+	path    string
+	entries map[string]cachedResolution
+}
+
+// cachedResolution : the version resolved for a directory and what it depends on
+type cachedResolution struct {
+	Files   []string `json:"files"`   // files the version sources read
+	Stamp   string   `json:"stamp"`   // digest of the modification times of the files and directories
+	Version string   `json:"version"` // exact version, empty when no version source sets one
+	Source  string   `json:"source,omitempty"`
+}
+
+// LoadResolutionCache : read the resolution cache of the product from its install location. A missing or
+// unreadable cache is empty
+func (product *Product) LoadResolutionCache() (*ResolutionCache, error) {
+	installLocation, err := product.InstallLocation()
+	if err != nil {
+		return nil, err
+	}
+
+	cache := &ResolutionCache{path: filepath.Join(installLocation, resolutionCacheFile), entries: map[string]cachedResolution{}}
+	if content, err := ioutil.ReadFile(cache.path); err == nil {
+		json.Unmarshal(content, &cache.entries) //a corrupted cache is rebuilt
+	}
+	return cache, nil
+}
+
+// Lookup : get the version cached for the directory and the source it was read from. The key holds the settings
+// the resolution depends on besides files, such as environment variables
+func (cache *ResolutionCache) Lookup(dir string, key string) (string, string, bool) {
+	entry, found := cache.entries[dir]
+	if !found || entry.Stamp != resolutionStamp(dir, entry.Files, key) {
+		return "", "", false
+	}
+	return entry.Version, entry.Source, true
+}
+
+// Store : cache the exact version resolved for the directory and where it was read from, with the files read by
+// the version sources
+func (cache *ResolutionCache) Store(dir string, key string, source string, resolution *Resolution, version string) {
+	files := resolutionFiles(resolution)
+	cache.entries[dir] = cachedResolution{
+		Files:   files,
+		Stamp:   resolutionStamp(dir, files, key),
+		Version: version,
+		Source:  source,
+	}
+}
+
+// Save : write the cache, replacing the previous cache at once
+func (cache *ResolutionCache) Save() error {
+	content, err := json.Marshal(cache.entries)
+	if err != nil {
+		return err
+	}
+
+	tmpFile := fmt.Sprintf("%s.%d.tmp", cache.path, os.Getpid())
+	if err := ioutil.WriteFile(tmpFile, content, 0644); err != nil {
+		return pathError(tmpFile, err)
+	}
+	if err := os.Rename(tmpFile, cache.path); err != nil {
+		os.Remove(tmpFile)
+		return pathError(cache.path, err)
+	}
+	return nil
+}
+
+// resolutionFiles : the existing files at the locations of the trace, such as the *.tf files of the module
+func resolutionFiles(resolution *Resolution) []string {
+	var files []string
+	for _, entry := range resolution.Trace {
+		matches, _ := filepath.Glob(entry.Location)
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && info.Mode().IsRegular() {
+				files = append(files, match)
+			}
+		}
+	}
+	return files
+}
+
+// resolutionStamp : digest of the key and of the modification times of the files, of the directory and of its parents
+func resolutionStamp(dir string, files []string, key string) string {
+	digest := sha256.New()
+	fmt.Fprintf(digest, "%s\x00", key)
+
+	stamp := func(path string) {
+		if info, err := os.Stat(path); err == nil {
+			fmt.Fprintf(digest, "%s\x00%d\x00%d\x00", path, info.ModTime().UnixNano(), info.Size())
+		} else {
+			fmt.Fprintf(digest, "%s\x00missing\x00", path)
+		}
+	}
+
+	for current := dir; ; current = filepath.Dir(current) {
+		stamp(current)
+		if filepath.Dir(current) == current {
+			break
+		}
+	}
+	for _, file := range files {
+		stamp(file)
+	}
+	return hex.EncodeToString(digest.Sum(nil))
+}
@@ -0,0 +1,94 @@
+package lib_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+// TestResolutionCache : a cached version is used until a version file is edited or a file is added to the directory
+func TestResolutionCache(t *testing.T) {
+
+	newTestInstallLocation(t, "0.38.0")
+	project := t.TempDir()
+	dir := filepath.Join(project, "live")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	versionFile := filepath.Join(project, lib.TGVersionFilename)
+	if err := os.WriteFile(versionFile, []byte("0.38.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	/* resolve, store and read the cache back from disk */
+	resolve := func() {
+		resolution, err := lib.NewResolver(lib.NewFileSource(lib.TGVersionFilename, "")).Resolve(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cache, err := lib.Terragrunt.LoadResolutionCache()
+		if err != nil {
+			t.Fatal(err)
+		}
+		cache.Store(dir, "key", resolution.Location, resolution, resolution.Version)
+		if err := cache.Save(); err != nil {
+			t.Fatalf("Unable to save the cache %v [unexpected]", err)
+		}
+	}
+	lookup := func(key string) (string, bool) {
+		cache, err := lib.Terragrunt.LoadResolutionCache()
+		if err != nil {
+			t.Fatal(err)
+		}
+		version, _, found := cache.Lookup(dir, key)
+		return version, found
+	}
+	/* modification times can be too coarse to tell writes apart */
+	touch := func(path string) {
+		later := time.Now().Add(time.Minute)
+		if err := os.Chtimes(path, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, found := lookup("key"); found {
+		t.Error("Empty cache should miss [unexpected]")
+	}
+
+	resolve()
+	if version, found := lookup("key"); !found || version != "0.38.0" {
+		t.Errorf("Expected 0.38.0 from the cache, got %q %v [unexpected]", version, found)
+	} else {
+		t.Logf("Cached version %s [expected]", version)
+	}
+	if _, found := lookup("other"); found {
+		t.Error("Different key should miss [unexpected]")
+	}
+
+	if err := os.WriteFile(versionFile, []byte("0.37.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	touch(versionFile)
+	if _, found := lookup("key"); found {
+		t.Error("Edited version file should invalidate the cache [unexpected]")
+	} else {
+		t.Log("Edited version file invalidated the cache [expected]")
+	}
+
+	resolve()
+	if version, found := lookup("key"); !found || version != "0.37.0" {
+		t.Errorf("Expected 0.37.0 from the cache, got %q %v [unexpected]", version, found)
+	}
+	if err := os.WriteFile(filepath.Join(dir, lib.TGVersionFilename), []byte("0.36.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	touch(dir)
+	if _, found := lookup("key"); found {
+		t.Error("New version file in the directory should invalidate the cache [unexpected]")
+	} else {
+		t.Log("New version file invalidated the cache [expected]")
+	}
+}
@@ -0,0 +1,86 @@
+package lib
+
+// status of a version source in the resolution trace
+const (
+	SourceSelected   = "selected"   // the source provided the version
+	SourceOverridden = "overridden" // the source sets a version, but a source with higher precedence was selected
+	SourceNotSet     = "not set"    // the source does not set a version
+	SourceFailed     = "error"      // the source could not be read
+	SourceLocked     = "locked"     // the lock file pins the version the selected source sets
+)
+
+// CommandLineSource : name of the source of a version provided on the command line
+const CommandLineSource = "command line"
+
+// VersionSource : a place a terragrunt version or constraint can be read from, such as a file or an environment variable
+type VersionSource interface {
+	// Name : name of the source shown in the resolution trace
+	Name() string
+	// Lookup : read the version or constraint the source sets for the directory, and where it was looked up.
+	// An empty version means the source does not set one
+	Lookup(dir string) (version string, location string, err error)
+}
+
+// TraceEntry : the outcome of one version source
+type TraceEntry struct {
+	Source   string `json:"source"`
+	Location string `json:"location,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Resolution : the version or constraint chosen by the resolver, the source it was read from and the full decision trace
+type Resolution struct {
+	Version  string       `json:"version"` // exact version or constraint, empty when no source sets one
+	Source   string       `json:"source,omitempty"`
+	Location string       `json:"location,omitempty"`
+	Required string       `json:"required,omitempty"` // version or constraint the source sets, when the lock pins it
+	Lock     string       `json:"lock,omitempty"`     // lock file pinning the version, see ApplyLock
+	Trace    []TraceEntry `json:"trace"`
+}
+
+// Resolver : evaluates version sources in order of precedence, the first source that sets a version wins
+type Resolver struct {
+	sources []VersionSource
+}
+
+// NewResolver : create a resolver for the sources, from highest to lowest precedence
+func NewResolver(sources ...VersionSource) *Resolver {
+	return &Resolver{sources: sources}
+}
+
+// Resolve : choose the version for the directory. Every source is evaluated so the trace explains the decision.
+// A source that fails before a version was selected stops the resolution with its error
+func (resolver *Resolver) Resolve(dir string) (*Resolution, error) {
+
+	resolution := &Resolution{Trace: []TraceEntry{}}
+	var resolveErr error
+
+	for _, source := range resolver.sources {
+		version, location, err := source.Lookup(dir)
+		entry := TraceEntry{Source: source.Name(), Location: location, Version: version}
+
+		switch {
+		case err != nil:
+			entry.Status = SourceFailed
+			entry.Error = err.Error()
+			if resolution.Version == "" && resolveErr == nil {
+				resolveErr = err
+			}
+		case version == "":
+			entry.Status = SourceNotSet
+		case resolution.Version != "" || resolveErr != nil:
+			entry.Status = SourceOverridden
+		default:
+			entry.Status = SourceSelected
+			resolution.Version = version
+			resolution.Source = source.Name()
+			resolution.Location = location
+		}
+
+		resolution.Trace = append(resolution.Trace, entry)
+	}
+
+	return resolution, resolveErr
+}
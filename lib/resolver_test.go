@@ -0,0 +1,137 @@
+package lib_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+func writeTestFile(t *testing.T, path string, content string) {
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestResolverPrecedence : the source with the highest precedence wins, the trace explains every source
+func TestResolverPrecedence(t *testing.T) {
+
+	dir := t.TempDir()
+	t.Setenv("TG_VERSION", "0.38.0")
+	writeTestFile(t, filepath.Join(dir, ".terragrunt-version"), "0.26.7\n")
+	writeTestFile(t, filepath.Join(dir, "terragrunt.hcl"), `
+terragrunt_version_constraint = ">= 0.30"
+
+remote_state {
+  backend = "s3"
+}
+`)
+
+	resolver := lib.NewResolver(lib.DefaultVersionSources(lib.SourceOptions{ConfigVersion: "0.9.1", ConfigFile: "/home/user/.tgswitch.toml", StopAt: dir})...)
+	resolution, err := resolver.Resolve(dir)
+	if err != nil {
+		t.Fatalf("Unable to resolve version %v [unexpected]", err)
+	}
+
+	if resolution.Version == "0.26.7" && resolution.Source == ".terragrunt-version" {
+		t.Logf("Resolved %s from %s [expected]", resolution.Version, resolution.Source)
+	} else {
+		t.Errorf("Expected 0.26.7 from .terragrunt-version, got %s from %s [unexpected]", resolution.Version, resolution.Source)
+	}
+
+	expected := map[string]string{
+		"command line":                    lib.SourceNotSet,
+		".tgswitchrc":                     lib.SourceNotSet,
+		".terragrunt-version":             lib.SourceSelected,
+		"terragrunt.hcl":                  lib.SourceOverridden,
+		"required_version":                lib.SourceNotSet,
+		"TG_VERSION environment variable": lib.SourceOverridden,
+		".tgswitch.toml":                  lib.SourceOverridden,
+	}
+	if len(resolution.Trace) != len(expected) {
+		t.Errorf("Expected %d sources in the trace, got %v [unexpected]", len(expected), resolution.Trace)
+	}
+	for _, entry := range resolution.Trace {
+		if entry.Status != expected[entry.Source] {
+			t.Errorf("Expected %s to be %q, got %q [unexpected]", entry.Source, expected[entry.Source], entry.Status)
+		}
+	}
+
+	/* the command line argument overrides every file */
+	resolver = lib.NewResolver(lib.DefaultVersionSources(lib.SourceOptions{Version: "0.20.0", StopAt: dir})...)
+	if resolution, _ := resolver.Resolve(dir); resolution.Version != "0.20.0" {
+		t.Errorf("Expected command line version, got %s [unexpected]", resolution.Version)
+	}
+}
+
+// TestResolverSourceError : a source that cannot be read stops the resolution, unless a source with higher precedence was selected
+func TestResolverSourceError(t *testing.T) {
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "terragrunt.hcl"), "terragrunt_version_constraint = ")
+
+	resolver := lib.NewResolver(lib.DefaultVersionSources(lib.SourceOptions{StopAt: dir})...)
+	if _, err := resolver.Resolve(dir); err != nil {
+		t.Logf("Invalid terragrunt.hcl reported %v [expected]", err)
+	} else {
+		t.Error("Invalid terragrunt.hcl should be reported [unexpected]")
+	}
+
+	writeTestFile(t, filepath.Join(dir, ".tgswitchrc"), "0.38.0")
+	resolution, err := resolver.Resolve(dir)
+	if err != nil || resolution.Version != "0.38.0" {
+		t.Errorf("Expected 0.38.0 from .tgswitchrc, got %v %v [unexpected]", resolution, err)
+	}
+}
+
+// TestResolverNoVersion : no source sets a version
+func TestResolverNoVersion(t *testing.T) {
+
+	dir := t.TempDir()
+	t.Setenv("TG_VERSION", "")
+	resolver := lib.NewResolver(lib.DefaultVersionSources(lib.SourceOptions{StopAt: dir})...)
+	resolution, err := resolver.Resolve(dir)
+	if err != nil || resolution.Version != "" {
+		t.Errorf("Expected no version, got %v %v [unexpected]", resolution, err)
+	}
+}
+
+// TestResolverParentFolders : version files are looked up in the parent directories, up to the git root or the stop directory
+func TestResolverParentFolders(t *testing.T) {
+
+	root := t.TempDir()
+	repo := filepath.Join(root, "live")
+	leaf := filepath.Join(repo, "prod", "eu-west-1", "vpc")
+	if err := os.MkdirAll(leaf, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TG_VERSION", "")
+
+	writeTestFile(t, filepath.Join(root, ".tgswitchrc"), "0.20.0")
+	writeTestFile(t, filepath.Join(repo, ".terragrunt-version"), "0.38.0")
+	writeTestFile(t, filepath.Join(repo, "prod", ".terragrunt-version"), "0.36.0")
+
+	/* the nearest file wins, the file outside of the git repository is ignored */
+	resolution, err := lib.NewResolver(lib.DefaultVersionSources(lib.SourceOptions{})...).Resolve(leaf)
+	expectedPath := filepath.Join(repo, "prod", ".terragrunt-version")
+	if err == nil && resolution.Version == "0.36.0" && resolution.Location == expectedPath {
+		t.Logf("Resolved %s from %s [expected]", resolution.Version, resolution.Location)
+	} else {
+		t.Errorf("Expected 0.36.0 from %s, got %v %v [unexpected]", expectedPath, resolution, err)
+	}
+
+	/* the search stops at the stop directory */
+	if _, found := lib.FindInParentFolders(leaf, ".terragrunt-version", filepath.Join(repo, "prod", "eu-west-1")); found {
+		t.Error("File above the stop directory should not be found [unexpected]")
+	}
+
+	/* a stop directory outside of the git repository lifts the git root boundary */
+	path, found := lib.FindInParentFolders(leaf, ".tgswitchrc", root)
+	if !found || path != filepath.Join(root, ".tgswitchrc") {
+		t.Errorf("Expected %s, got %q [unexpected]", filepath.Join(root, ".tgswitchrc"), path)
+	}
+}
@@ -11,14 +11,17 @@ import (
 func GetSemver(tgConstraint *string, mirrorURL *string) (string, error) {
 
 	listAll := true
-	tflist, _ := GetTGList(*mirrorURL, listAll) //get list of versions
-	fmt.Printf("Reading required version from constraint: %s\n", *tgConstraint)
-	tgVersion, err := SemVerParser(tgConstraint, tflist)
-	return tgVersion, err
+	fmt.Fprintf(output, "Reading required version from constraint: %s\n", *tgConstraint)
+	return Terragrunt.resolveConstraint(*tgConstraint, *mirrorURL, listAll)
 }
 
 // ValidateSemVer : Goes through the list of terragrunt version, return a valid tf version for contraint provided
 func SemVerParser(tgConstraint *string, tflist []string) (string, error) {
+	return semVerMatch(tgConstraint, tflist, false)
+}
+
+// semVerMatch : get the newest version of the list matching the constraint, or the oldest with oldest
+func semVerMatch(tgConstraint *string, tflist []string, oldest bool) (string, error) {
 	tgVersion := ""
 	constraints, err := semver.NewConstraint(*tgConstraint) //NewConstraint returns a Constraints instance that a Version instance can be checked against
 	if err != nil {
@@ -34,28 +37,56 @@ func SemVerParser(tgConstraint *string, tflist []string) (string, error) {
 		versions[i] = version
 	}
 
-	sort.Sort(sort.Reverse(semver.Collection(versions)))
+	if oldest {
+		sort.Sort(semver.Collection(versions))
+	} else {
+		sort.Sort(sort.Reverse(semver.Collection(versions)))
+	}
 
 	for _, element := range versions {
 		if constraints.Check(element) { // Validate a version against a constraint
 			tgVersion = element.String()
-			fmt.Printf("Matched version: %s\n", tgVersion)
+			fmt.Fprintf(output, "Matched version: %s\n", tgVersion)
 			if ValidVersionFormat(tgVersion) { //check if version format is correct
 				return tgVersion, nil
 			}
 		}
 	}
 
-	PrintInvalidTGVersion()
-	return "", fmt.Errorf("error parsing constraint: %s", *tgConstraint)
+	return "", &VersionNotFoundError{Version: *tgConstraint}
+}
+
+// sortedVersions : parse a list of versions, sorted from newest to oldest. Invalid versions are skipped
+func sortedVersions(tglist []string) []*semver.Version {
+	versions := make([]*semver.Version, 0, len(tglist))
+	for _, tgval := range tglist {
+		version, err := semver.NewVersion(tgval)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, version)
+	}
+
+	sort.Sort(sort.Reverse(semver.Collection(versions)))
+	return versions
 }
 
 // Print invalid TF version
 func PrintInvalidTGVersion() {
-	fmt.Println("Version does not exist or invalid terragrunt version format.\n Format should be #.#.# or #.#.#-@# where # are numbers and @ are word characters.\n For example, 0.11.7 and 0.11.9-beta1 are valid versions")
+	Terragrunt.PrintInvalidVersion()
+}
+
+// PrintInvalidVersion : print the expected version format of the product
+func (product *Product) PrintInvalidVersion() {
+	fmt.Fprintf(output, "Version does not exist or invalid %s version format.\n Format should be #.#.# or #.#.#-@# where # are numbers and @ are word characters.\n For example, 0.11.7 and 0.11.9-beta1 are valid versions\n", product.Name)
 }
 
 // Print invalid TF version
 func PrintInvalidMinorTGVersion() {
-	fmt.Println("Invalid minor terragrunt version format. Format should be #.# where # are numbers. For example, 0.11 is valid version")
+	Terragrunt.PrintInvalidMinorVersion()
+}
+
+// PrintInvalidMinorVersion : print the expected minor version format of the product
+func (product *Product) PrintInvalidMinorVersion() {
+	fmt.Fprintf(output, "Invalid minor %s version format. Format should be #.# where # are numbers. For example, 0.11 is valid version\n", product.Name)
 }
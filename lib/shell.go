@@ -0,0 +1,62 @@
+package lib
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// shells tgswitch prints commands for
+const (
+	ShellBash = "bash"
+	ShellZsh  = "zsh"
+	ShellFish = "fish"
+)
+
+// Shells : the shells tgswitch prints commands for
+var Shells = []string{ShellBash, ShellZsh, ShellFish}
+
+// DetectShell : the shell named by the path in $SHELL, bash when it is not a supported shell
+func DetectShell(shellPath string) string {
+	name := filepath.Base(shellPath)
+	for _, shell := range Shells {
+		if name == shell {
+			return shell
+		}
+	}
+	return ShellBash
+}
+
+// ValidShell : check the shell is one tgswitch prints commands for
+func ValidShell(shell string) error {
+	for _, supported := range Shells {
+		if shell == supported {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported shell %q, expected one of: %s", shell, strings.Join(Shells, ", "))
+}
+
+// ShellQuote : quote a value so the shell reads it as a single word
+func ShellQuote(shell string, value string) string {
+	if shell == ShellFish {
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// ShellExport : the command exporting an environment variable
+func ShellExport(shell string, name string, value string) string {
+	if shell == ShellFish {
+		return fmt.Sprintf("set -gx %s %s;", name, ShellQuote(shell, value))
+	}
+	return fmt.Sprintf("export %s=%s;", name, ShellQuote(shell, value))
+}
+
+// ShellUnset : the command removing an environment variable, it does not fail when the variable is not set
+func ShellUnset(shell string, name string) string {
+	if shell == ShellFish {
+		return fmt.Sprintf("set -e %s;", name)
+	}
+	return fmt.Sprintf("unset %s;", name)
+}
@@ -0,0 +1,65 @@
+package lib_test
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+// TestShellCommands : the commands printed for each shell
+func TestShellCommands(t *testing.T) {
+
+	tests := []struct {
+		shell    string
+		command  string
+		expected string
+	}{
+		{lib.ShellBash, lib.ShellExport(lib.ShellBash, "TERRAGRUNT_TFPATH", "/opt/tf"), "export TERRAGRUNT_TFPATH='/opt/tf';"},
+		{lib.ShellZsh, lib.ShellUnset(lib.ShellZsh, "TERRAGRUNT_TFPATH"), "unset TERRAGRUNT_TFPATH;"},
+		{lib.ShellFish, lib.ShellExport(lib.ShellFish, "TERRAGRUNT_TFPATH", "/opt/it's"), `set -gx TERRAGRUNT_TFPATH '/opt/it\'s';`},
+		{lib.ShellFish, lib.ShellUnset(lib.ShellFish, "TERRAGRUNT_TFPATH"), "set -e TERRAGRUNT_TFPATH;"},
+	}
+	for _, test := range tests {
+		if test.command != test.expected {
+			t.Errorf("%s: expected %s, got %s [unexpected]", test.shell, test.expected, test.command)
+		} else {
+			t.Logf("%s: %s [expected]", test.shell, test.command)
+		}
+	}
+
+	if shell := lib.DetectShell("/usr/local/bin/fish"); shell != lib.ShellFish {
+		t.Errorf("Expected fish, got %s [unexpected]", shell)
+	}
+	if shell := lib.DetectShell("/bin/tcsh"); shell != lib.ShellBash {
+		t.Errorf("Expected bash for an unsupported shell, got %s [unexpected]", shell)
+	}
+	if err := lib.ValidShell("tcsh"); err == nil {
+		t.Error("Unsupported shell should be reported [unexpected]")
+	}
+}
+
+// TestShellQuoteBash : bash reads the quoted exports back unchanged
+func TestShellQuoteBash(t *testing.T) {
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	value := `/home/o'neil/my dir/$HOME/terragrunt_0.38.0`
+	script := strings.Join([]string{
+		lib.ShellExport(lib.ShellBash, "TGSWITCH_TEST", value),
+		`printf '%s\n' "$TGSWITCH_TEST"`,
+		lib.ShellUnset(lib.ShellBash, "TGSWITCH_TEST"),
+		`printf '%s\n' "${TGSWITCH_TEST-unset}"`,
+	}, "\n")
+
+	out, err := exec.Command(bash, "-c", script).CombinedOutput()
+	if err != nil || string(out) != value+"\nunset\n" {
+		t.Errorf("Expected %q, got %q %v [unexpected]", value+"\nunset\n", out, err)
+	} else {
+		t.Logf("bash read %s [expected]", value)
+	}
+}
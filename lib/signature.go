@@ -0,0 +1,137 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"golang.org/x/crypto/openpgp"
+)
+
+// bundledKeys : trusted public keys compiled into tgswitch. Any armored key (*.asc) dropped in lib/keys is trusted,
+// none is bundled yet
+//
+//go:embed keys
+var bundledKeys embed.FS
+
+// SignatureVerifier : verifies detached signatures of release checksum files against a set of trusted keys
+type SignatureVerifier struct {
+	keyring openpgp.EntityList
+	skip    bool
+}
+
+var signatureVerifier *SignatureVerifier
+
+// NewSignatureVerifier : create a verifier trusting the bundled keys and the provided keys.
+// Each trusted key is either an armored public key or a path to a file containing one.
+// Several keys can be trusted at once to allow key rotation.
+func NewSignatureVerifier(trustedKeys []string, skip bool) (*SignatureVerifier, error) {
+	verifier := &SignatureVerifier{skip: skip}
+
+	entries, err := bundledKeys.ReadDir("keys")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".asc" {
+			continue
+		}
+		key, err := bundledKeys.ReadFile(path.Join("keys", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if err := verifier.addKey(key); err != nil {
+			return nil, fmt.Errorf("invalid bundled key %s: %w", entry.Name(), err)
+		}
+	}
+
+	for _, trustedKey := range trustedKeys {
+		key := []byte(trustedKey)
+		if !strings.Contains(trustedKey, "-----BEGIN PGP PUBLIC KEY BLOCK-----") {
+			keyPath, err := homedir.Expand(os.ExpandEnv(trustedKey))
+			if err != nil {
+				return nil, err
+			}
+			if key, err = ioutil.ReadFile(keyPath); err != nil {
+				return nil, fmt.Errorf("unable to read trusted key %s: %w", trustedKey, err)
+			}
+		}
+		if err := verifier.addKey(key); err != nil {
+			return nil, fmt.Errorf("invalid trusted key %s: %w", trustedKey, err)
+		}
+	}
+
+	return verifier, nil
+}
+
+func (verifier *SignatureVerifier) addKey(key []byte) error {
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(key))
+	if err != nil {
+		return err
+	}
+	verifier.keyring = append(verifier.keyring, entities...)
+	return nil
+}
+
+// Verify : download the detached signature published next to the checksum file (<checksum file>.sig) and verify it
+func (verifier *SignatureVerifier) Verify(ctx context.Context, checksumURL string, checksums []byte) error {
+	return verifier.VerifyURL(ctx, checksumURL, checksumURL+".sig", checksums)
+}
+
+// VerifyURL : download the detached signature of the checksum file from signatureURL and verify it
+func (verifier *SignatureVerifier) VerifyURL(ctx context.Context, checksumURL string, signatureURL string, checksums []byte) error {
+	if verifier.skip {
+		fmt.Fprintln(output, "[WARNING] : Signature verification is DISABLED (--skip-signature).")
+		fmt.Fprintf(output, "[WARNING] : The authenticity of %s has NOT been verified.\n", checksumURL)
+		return nil
+	}
+
+	/* no release key is bundled yet, without trusted_keys only the checksum of the download is verified */
+	if len(verifier.keyring) == 0 {
+		fmt.Fprintln(output, "[WARNING] : No trusted keys, signature verification is NOT performed. Set trusted_keys in .tgswitch.toml.")
+		fmt.Fprintf(output, "[WARNING] : The authenticity of %s has NOT been verified, only the checksum of the download.\n", checksumURL)
+		return nil
+	}
+
+	signature, err := DownloadBytesContext(ctx, signatureURL)
+	if err == nil {
+		err = verifier.CheckSignature(checksums, signature)
+	}
+	if err == context.Canceled {
+		return err
+	}
+	if err != nil {
+		return &SignatureError{URL: checksumURL, Err: err}
+	}
+	return nil
+}
+
+// CheckSignature : check that the detached signature, armored or binary, over data was made by a trusted key
+func (verifier *SignatureVerifier) CheckSignature(data []byte, signature []byte) error {
+	var (
+		signer *openpgp.Entity
+		err    error
+	)
+	if bytes.HasPrefix(bytes.TrimSpace(signature), []byte("-----BEGIN PGP SIGNATURE-----")) {
+		signer, err = openpgp.CheckArmoredDetachedSignature(verifier.keyring, bytes.NewReader(data), bytes.NewReader(signature))
+	} else {
+		signer, err = openpgp.CheckDetachedSignature(verifier.keyring, bytes.NewReader(data), bytes.NewReader(signature))
+	}
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+
+	fmt.Fprintf(output, "Verified signature from key %s\n", signer.PrimaryKey.KeyIdString())
+	return nil
+}
+
+// SetSignatureVerifier : set the verifier used when installing terragrunt
+func SetSignatureVerifier(verifier *SignatureVerifier) {
+	signatureVerifier = verifier
+}
@@ -0,0 +1,160 @@
+package lib_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+
+	"github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+func newTestKey(t *testing.T, name string) (*openpgp.Entity, string) {
+	entity, err := openpgp.NewEntity(name, "test", name+"@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var key bytes.Buffer
+	writer, err := armor.Encode(&key, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(writer); err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+
+	return entity, key.String()
+}
+
+func signTestData(t *testing.T, entity *openpgp.Entity, data []byte) []byte {
+	var signature bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&signature, entity, bytes.NewReader(data), nil); err != nil {
+		t.Fatal(err)
+	}
+	return signature.Bytes()
+}
+
+// newTestMirror : serve a checksum file and its detached signature like a release mirror
+func newTestMirror(checksums []byte, signature []byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v0.26.7/SHA256SUMS":
+			w.Write(checksums)
+		case "/v0.26.7/SHA256SUMS.sig":
+			w.Write(signature)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// TestSignatureVerifier : verify the signature of a checksum file against trusted keys
+func TestSignatureVerifier(t *testing.T) {
+
+	checksums := []byte(testBinaryDigest + "  terragrunt_linux_amd64\n")
+	oldKey, oldArmored := newTestKey(t, "old release key")
+	newKey, newArmored := newTestKey(t, "new release key")
+	_, untrustedArmored := newTestKey(t, "untrusted key")
+
+	/* the new key is trusted from a file, the old key inline */
+	newKeyFile := filepath.Join(t.TempDir(), "release.asc")
+	if err := os.WriteFile(newKeyFile, []byte(newArmored), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := lib.NewSignatureVerifier([]string{oldArmored, newKeyFile}, false)
+	if err != nil {
+		t.Fatalf("Unable to create verifier %v [unexpected]", err)
+	}
+
+	t.Run("Signature from any trusted key is valid",
+		func(t *testing.T) {
+			for _, key := range []*openpgp.Entity{oldKey, newKey} {
+				mirror := newTestMirror(checksums, signTestData(t, key, checksums))
+				if err := verifier.Verify(context.Background(), mirror.URL+"/v0.26.7/SHA256SUMS", checksums); err != nil {
+					t.Errorf("Signature should be valid %v [unexpected]", err)
+				}
+				mirror.Close()
+			}
+		},
+	)
+
+	t.Run("Tampered checksums are rejected",
+		func(t *testing.T) {
+			mirror := newTestMirror(checksums, signTestData(t, newKey, checksums))
+			defer mirror.Close()
+
+			tampered := []byte(testBinaryDigest + "  terragrunt_darwin_amd64\n")
+			if err := verifier.Verify(context.Background(), mirror.URL+"/v0.26.7/SHA256SUMS", tampered); err == nil {
+				t.Error("Tampered checksums should be rejected [unexpected]")
+			} else {
+				t.Logf("Tampered checksums rejected %v [expected]", err)
+			}
+		},
+	)
+
+	t.Run("Signature from an untrusted key is rejected",
+		func(t *testing.T) {
+			untrusted, err := lib.NewSignatureVerifier([]string{untrustedArmored}, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mirror := newTestMirror(checksums, signTestData(t, newKey, checksums))
+			defer mirror.Close()
+
+			if err := untrusted.Verify(context.Background(), mirror.URL+"/v0.26.7/SHA256SUMS", checksums); err == nil {
+				t.Error("Signature from untrusted key should be rejected [unexpected]")
+			} else {
+				t.Logf("Signature from untrusted key rejected %v [expected]", err)
+			}
+		},
+	)
+
+	t.Run("Missing signature is rejected unless skipped",
+		func(t *testing.T) {
+			mirror := newTestMirror(checksums, nil)
+			defer mirror.Close()
+
+			if err := verifier.Verify(context.Background(), mirror.URL+"/v0.0.0/SHA256SUMS", checksums); err == nil {
+				t.Error("Missing signature should be rejected [unexpected]")
+			}
+
+			skipping, err := lib.NewSignatureVerifier([]string{newArmored}, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := skipping.Verify(context.Background(), mirror.URL+"/v0.0.0/SHA256SUMS", checksums); err != nil {
+				t.Errorf("Skipped verification should not fail %v [unexpected]", err)
+			}
+		},
+	)
+
+	t.Run("Empty keyring only verifies the checksum, loudly",
+		func(t *testing.T) {
+			mirror := newTestMirror(checksums, nil)
+			defer mirror.Close()
+
+			var messages bytes.Buffer
+			lib.SetOutput(&messages)
+			defer lib.SetOutput(os.Stdout)
+
+			empty := &lib.SignatureVerifier{}
+			if err := empty.Verify(context.Background(), mirror.URL+"/v0.26.7/SHA256SUMS", checksums); err != nil {
+				t.Errorf("Empty keyring should not fail %v [unexpected]", err)
+			}
+			if !strings.Contains(messages.String(), "signature verification is NOT performed") {
+				t.Errorf("Expected a warning, got %q [unexpected]", messages.String())
+			}
+		},
+	)
+}
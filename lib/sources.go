@@ -0,0 +1,188 @@
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+)
+
+const (
+	RCFilename               = ".tgswitchrc"         //version file of tgswitch (backward compatible purpose)
+	TGVersionFilename        = ".terragrunt-version" //version file of tgenv
+	HCLFilename              = "terragrunt.hcl"      //terragrunt configuration with terragrunt_version_constraint
+	VersionEnv               = "TG_VERSION"          //environment variable with the version
+	TerraformVersionFilename = ".terraform-version"  //version file of tfenv
+	TerraformVersionEnv      = "TF_VERSION"          //environment variable with the terraform version
+	OpenTofuVersionFilename  = ".opentofu-version"   //version file of tofuenv
+	OpenTofuVersionEnv       = "TOFU_VERSION"        //environment variable with the tofu version
+)
+
+// SourceOptions : settings of the default version sources
+type SourceOptions struct {
+	Version       string // version provided on the command line
+	ConfigVersion string // version set in the .tgswitch.toml file
+	ConfigFile    string // the .tgswitch.toml file in use
+	StopAt        string // directory the search for version files stops at, the git root when empty
+}
+
+// DefaultVersionSources : the sources tgswitch reads the version from, from highest to lowest precedence.
+// Versions that were not provided are empty
+func DefaultVersionSources(options SourceOptions) []VersionSource {
+	return Terragrunt.VersionSources(options)
+}
+
+// TerraformVersionSources : the sources tgswitch reads the terraform version from, from highest to lowest
+// precedence. The version in the .tgswitch.toml file is passed in the options
+func TerraformVersionSources(options SourceOptions) []VersionSource {
+	return Terraform.VersionSources(options)
+}
+
+// VersionSources : the sources the version of the product is read from, from highest to lowest precedence:
+// the command line, the version files, the constraint in terragrunt.hcl, the required_version of the module,
+// the environment variable and the .tgswitch.toml file
+func (product *Product) VersionSources(options SourceOptions) []VersionSource {
+	sources := []VersionSource{NewStaticSource(CommandLineSource, "", options.Version)}
+	for _, fileName := range product.VersionFiles {
+		sources = append(sources, NewFileSource(fileName, options.StopAt))
+	}
+	return append(sources,
+		NewHCLAttributeSource(HCLFilename, product.ConstraintAttribute, options.StopAt),
+		NewModuleSource(),
+		NewEnvSource(product.VersionEnv),
+		NewStaticSource(".tgswitch.toml", options.ConfigFile, options.ConfigVersion),
+	)
+}
+
+// FindInParentFolders : find the nearest file named fileName in dir or its parent directories, like the
+// find_in_parent_folders function of terragrunt. The search stops at stopAt, or when stopAt is empty at
+// the root of the git repository dir belongs to. Outside of stopAt or a git repository, the search goes
+// up to the filesystem root
+func FindInParentFolders(dir string, fileName string, stopAt string) (string, bool) {
+	for _, folder := range parentFolders(dir, stopAt) {
+		path := filepath.Join(folder, fileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// parentFolders : the absolute paths of dir and of the parent directories FindInParentFolders searches, nearest first
+func parentFolders(dir string, stopAt string) []string {
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+	if stopAt != "" {
+		if stopAt, err = filepath.Abs(stopAt); err != nil {
+			return nil
+		}
+	}
+
+	var folders []string
+	for {
+		folders = append(folders, dir)
+		if dir == stopAt || (stopAt == "" && CheckFileExist(filepath.Join(dir, ".git"))) {
+			return folders
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return folders
+		}
+		dir = parent
+	}
+}
+
+// staticSource : a version known up front, such as a command line argument
+type staticSource struct {
+	name     string
+	location string
+	version  string
+}
+
+// NewStaticSource : a source that always sets the provided version, unless it is empty
+func NewStaticSource(name string, location string, version string) VersionSource {
+	return &staticSource{name: name, location: location, version: version}
+}
+
+func (source *staticSource) Name() string {
+	return source.name
+}
+
+func (source *staticSource) Lookup(dir string) (string, string, error) {
+	return strings.TrimSpace(source.version), source.location, nil
+}
+
+// fileSource : a file that only contains the version, such as .terragrunt-version
+type fileSource struct {
+	fileName string
+	stopAt   string
+}
+
+// NewFileSource : a source reading the version from the nearest file named fileName in the directory or its
+// parents, up to stopAt. See FindInParentFolders
+func NewFileSource(fileName string, stopAt string) VersionSource {
+	return &fileSource{fileName: fileName, stopAt: stopAt}
+}
+
+func (source *fileSource) Name() string {
+	return source.fileName
+}
+
+func (source *fileSource) Lookup(dir string) (string, string, error) {
+	path, found := FindInParentFolders(dir, source.fileName, source.stopAt)
+	if !found {
+		return "", filepath.Join(dir, source.fileName), nil
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", path, pathError(path, err)
+	}
+	return strings.TrimSpace(string(content)), path, nil
+}
+
+// envSource : an environment variable set to the version
+type envSource struct {
+	variable string
+}
+
+// NewEnvSource : a source reading the version from an environment variable
+func NewEnvSource(variable string) VersionSource {
+	return &envSource{variable: variable}
+}
+
+func (source *envSource) Name() string {
+	return source.variable + " environment variable"
+}
+
+func (source *envSource) Lookup(dir string) (string, string, error) {
+	return strings.TrimSpace(os.Getenv(source.variable)), "$" + source.variable, nil
+}
+
+// moduleSource : the required_version of the terraform module in the directory
+type moduleSource struct{}
+
+// NewModuleSource : a source reading the version constraint from the required_version of the *.tf files
+func NewModuleSource() VersionSource {
+	return &moduleSource{}
+}
+
+func (source *moduleSource) Name() string {
+	return "required_version"
+}
+
+func (source *moduleSource) Lookup(dir string) (string, string, error) {
+	location := filepath.Join(dir, "*.tf")
+
+	module, _ := tfconfig.LoadModule(dir)
+	if module == nil || len(module.RequiredCore) == 0 {
+		return "", location, nil
+	}
+	return module.RequiredCore[0], location, nil //we skip duplicated definitions and use only first one
+}
@@ -0,0 +1,50 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// strategies choosing among the versions matching a constraint
+const (
+	StrategyNewest          = "newest"           // the newest version available for download
+	StrategyPreferInstalled = "prefer-installed" // the newest installed version, the newest version available for download when none is installed
+	StrategyOldest          = "oldest"           // the oldest version available for download
+)
+
+// Strategies : the strategies choosing among the versions matching a constraint
+var Strategies = []string{StrategyNewest, StrategyPreferInstalled, StrategyOldest}
+
+var strategy = StrategyNewest
+
+// SetStrategy : set the strategy choosing among the versions matching a constraint
+func SetStrategy(name string) error {
+	for _, supported := range Strategies {
+		if name == supported {
+			strategy = name
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown strategy %q, expected one of: %s", name, strings.Join(Strategies, ", "))
+}
+
+// resolveConstraint : get the version matching a constraint according to the strategy. With prefer-installed, the
+// version list is not downloaded when an installed version matches
+func (product *Product) resolveConstraint(constraint string, versionURL string, preRelease bool) (string, error) {
+	if strategy == StrategyPreferInstalled {
+		installed, err := product.InstalledVersions()
+		if err != nil {
+			return "", err
+		}
+		if version, err := semVerMatch(&constraint, installed, false); err == nil {
+			return version, nil
+		}
+	}
+
+	versions, installedOnly, err := product.listVersions(versionURL, preRelease)
+	if err != nil {
+		return "", err
+	}
+	version, err := semVerMatch(&constraint, versions, strategy == StrategyOldest)
+	return version, product.notFoundError(err, installedOnly)
+}
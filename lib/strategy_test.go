@@ -0,0 +1,55 @@
+package lib_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+// TestStrategy : the strategy chooses among the versions matching a constraint
+func TestStrategy(t *testing.T) {
+
+	newTestInstallLocation(t, "0.26.8")
+	t.Cleanup(func() { lib.SetStrategy(lib.StrategyNewest) })
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"Versions": ["0.27.0", "0.26.9", "0.26.8", "0.26.7"]}`))
+	}))
+	defer server.Close()
+
+	constraint := ">= 0.26.7, < 0.27"
+	if err := lib.SetStrategy(lib.StrategyPreferInstalled); err != nil {
+		t.Fatal(err)
+	}
+	if version, err := lib.Terragrunt.ResolveVersion(constraint, server.URL); err != nil || version != "0.26.8" || requests != 0 {
+		t.Errorf("Expected the installed 0.26.8 without downloading the version list, got %s %v after %d requests [unexpected]", version, err, requests)
+	} else {
+		t.Logf("Preferred the installed version %s [expected]", version)
+	}
+	if version, err := lib.Terragrunt.ResolveVersion(">= 0.27", server.URL); err != nil || version != "0.27.0" {
+		t.Errorf("Expected 0.27.0 when no installed version matches, got %s %v [unexpected]", version, err)
+	}
+
+	tests := map[string]string{
+		lib.StrategyNewest: "0.26.9",
+		lib.StrategyOldest: "0.26.7",
+	}
+	for strategy, expected := range tests {
+		if err := lib.SetStrategy(strategy); err != nil {
+			t.Fatal(err)
+		}
+		if version, err := lib.Terragrunt.ResolveVersion(constraint, server.URL); err != nil || version != expected {
+			t.Errorf("Expected %s with %s, got %s %v [unexpected]", expected, strategy, version, err)
+		} else {
+			t.Logf("Resolved %s with %s [expected]", version, strategy)
+		}
+	}
+
+	if err := lib.SetStrategy("latest"); err == nil {
+		t.Error("Unknown strategy should be reported [unexpected]")
+	}
+}
@@ -1,53 +1,43 @@
 package lib
 
 import (
-	"log"
+	"fmt"
 	"os"
+	"time"
 )
 
+// symlinkError : describe a failed symlink operation and how to fix it manually
+func symlinkError(action string, symlinkPath string, err error) error {
+	return pathError(symlinkPath, fmt.Errorf(`unable to %s symlink.
+	Maybe symlink already exist. Try removing existing symlink manually.
+	Try running "unlink %s" to remove existing symlink.
+	If error persist, you may not have the permission to create a symlink at %s.
+	Error: %w`, action, symlinkPath, symlinkPath, err))
+}
+
 //CreateSymlink : create symlink
-func CreateSymlink(cwd string, dir string) {
+func CreateSymlink(cwd string, dir string) error {
 
 	err := os.Symlink(cwd, dir)
 	if err != nil {
-		log.Fatalf(`
-		Unable to create new symlink.
-		Maybe symlink already exist. Try removing existing symlink manually.
-		Try running "unlink %s" to remove existing symlink.
-		If error persist, you may not have the permission to create a symlink at %s.
-		Error: %s
-		`, dir, dir, err)
-		os.Exit(1)
+		return symlinkError("create new", dir, err)
 	}
+	return nil
 }
 
 //RemoveSymlink : remove symlink
-func RemoveSymlink(symlinkPath string) {
+func RemoveSymlink(symlinkPath string) error {
 
 	_, err := os.Lstat(symlinkPath)
 	if err != nil {
-		log.Fatalf(`
-		Unable to stat symlink.
-		Maybe symlink already exist. Try removing existing symlink manually.
-		Try running "unlink %s" to remove existing symlink.
-		If error persist, you may not have the permission to create a symlink at %s.
-		Error: %s
-		`, symlinkPath, symlinkPath, err)
-		os.Exit(1)
-	} else {
-		errRemove := os.Remove(symlinkPath)
-
-		if errRemove != nil {
-			log.Fatalf(`
-			Unable to remove symlink.
-			Maybe symlink already exist. Try removing existing symlink manually.
-			Try running "unlink %s" to remove existing symlink.
-			If error persist, you may not have the permission to create a symlink at %s.
-			Error: %s
-			`, symlinkPath, symlinkPath, errRemove)
-			os.Exit(1)
-		}
+		return symlinkError("stat", symlinkPath, err)
+	}
+
+	errRemove := os.Remove(symlinkPath)
+	if errRemove != nil {
+		return symlinkError("remove", symlinkPath, errRemove)
 	}
+	return nil
 }
 
 // CheckSymlink : check file is symlink
@@ -65,19 +55,32 @@ func CheckSymlink(symlinkPath string) bool {
 	return false
 }
 
-// ChangeSymlink : move symlink to existing binary
-func ChangeSymlink(binVersionPath string, binPath string) {
+// ReplaceSymlink : point a symlink to a new target without removing it first.
+// A new symlink is created next to the old one and renamed over it, so the symlink path
+// always resolves to either the old or the new target
+func ReplaceSymlink(target string, symlinkPath string) error {
 
-	//installLocation = GetInstallLocation() //get installation location -  this is where we will put our terraform binary file
-	binPath = InstallableBinLocation(binPath)
+	tmpSymlink := fmt.Sprintf("%s.%d.%d.tmp", symlinkPath, os.Getpid(), time.Now().UnixNano())
 
-	/* remove current symlink if exist*/
-	symlinkExist := CheckSymlink(binPath)
-	if symlinkExist {
-		RemoveSymlink(binPath)
+	if err := os.Symlink(target, tmpSymlink); err != nil {
+		return symlinkError("create new", symlinkPath, err)
 	}
 
-	/* set symlink to desired version */
-	CreateSymlink(binVersionPath, binPath)
+	if err := os.Rename(tmpSymlink, symlinkPath); err != nil {
+		os.Remove(tmpSymlink)
+		return symlinkError("replace", symlinkPath, err)
+	}
+	return nil
+}
+
+// ChangeSymlink : move symlink to existing binary. The symlink is replaced atomically,
+// a file that is not a symlink is never overwritten
+func ChangeSymlink(binVersionPath string, binPath string) error {
 
+	if _, err := os.Lstat(binPath); err == nil && !CheckSymlink(binPath) {
+		return symlinkError("replace", binPath, fmt.Errorf("%s exists and is not a symlink", binPath))
+	}
+
+	/* set symlink to desired version */
+	return ReplaceSymlink(binVersionPath, binPath)
 }
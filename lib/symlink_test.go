@@ -117,3 +117,79 @@ func TestCheckSymlink(t *testing.T) {
 
 	os.Remove(symlinkPathSrc)
 }
+
+// TestReplaceSymlink : swap a symlink between two targets many times while checking
+// that the symlink never goes missing
+func TestReplaceSymlink(t *testing.T) {
+
+	tempDir := t.TempDir()
+	targets := []string{filepath.Join(tempDir, "terragrunt_0.26.6"), filepath.Join(tempDir, "terragrunt_0.26.7")}
+	for _, target := range targets {
+		createFile(target)
+	}
+	symlinkPath := filepath.Join(tempDir, "terragrunt")
+
+	if err := lib.ReplaceSymlink(targets[0], symlinkPath); err != nil {
+		t.Fatalf("Unable to create symlink %v [unexpected]", err)
+	}
+
+	done := make(chan struct{})
+	missing := make(chan error, 1)
+	go func() {
+		for {
+			select {
+			case <-done:
+				close(missing)
+				return
+			default:
+			}
+			if _, err := os.Stat(symlinkPath); err != nil {
+				missing <- err
+				close(missing)
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		if err := lib.ReplaceSymlink(targets[i%2], symlinkPath); err != nil {
+			t.Fatalf("Unable to replace symlink %v [unexpected]", err)
+		}
+	}
+	close(done)
+
+	if err := <-missing; err != nil {
+		t.Errorf("Symlink went missing during switch %v [unexpected]", err)
+	} else {
+		t.Log("Symlink always available during switch [expected]")
+	}
+
+	if ln, _ := os.Readlink(symlinkPath); ln != targets[1] {
+		t.Errorf("Symlink points to %v instead of %v [unexpected]", ln, targets[1])
+	}
+
+	files, _ := filepath.Glob(filepath.Join(tempDir, "terragrunt.*"))
+	if len(files) != 0 {
+		t.Errorf("Temporary symlinks left behind %v [unexpected]", files)
+	}
+}
+
+// TestChangeSymlinkRegularFile : a regular file at the bin path is never overwritten
+func TestChangeSymlinkRegularFile(t *testing.T) {
+
+	tempDir := t.TempDir()
+	target := filepath.Join(tempDir, "terragrunt_0.26.7")
+	binPath := filepath.Join(tempDir, "terragrunt")
+	createFile(target)
+	createFile(binPath)
+
+	if err := lib.ChangeSymlink(target, binPath); err == nil {
+		t.Error("Regular file should not be replaced [unexpected]")
+	} else {
+		t.Logf("Regular file not replaced %v [expected]", err)
+	}
+
+	if lib.CheckSymlink(binPath) {
+		t.Error("Regular file was replaced by a symlink [unexpected]")
+	}
+}
@@ -0,0 +1,207 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	semver "github.com/hashicorp/go-version"
+)
+
+// PrunePolicy : which installed versions to keep when pruning. A version is removed when no policy keeps it
+type PrunePolicy struct {
+	KeepRecent      int           // keep the N most recently used versions
+	KeepLatestPatch bool          // keep the newest patch release of each minor version
+	UnusedFor       time.Duration // only remove versions that were not used for this long
+	Force           bool          // also remove the version in use and the versions in the recent file
+	DryRun          bool          // only report the versions that would be removed
+}
+
+// empty : true when the policy would remove every version
+func (policy PrunePolicy) empty() bool {
+	return policy.KeepRecent <= 0 && !policy.KeepLatestPatch && policy.UnusedFor <= 0
+}
+
+// UninstallVersion : remove an installed version from the install location.
+// The version the bin path points to is never removed
+func UninstallVersion(ctx context.Context, tgVersion string, binPath string) error {
+
+	if !ValidVersionFormat(tgVersion) {
+		return &InvalidVersionError{Version: tgVersion}
+	}
+
+	_, err := Terragrunt.UninstallVersions(ctx, tgVersion, binPath, false, false)
+	return err
+}
+
+// UninstallVersions : remove the installed versions matching a version or a constraint. The version the bin
+// path points to is only removed when forced, with dryRun nothing is removed. Returns the matching versions
+func UninstallVersions(ctx context.Context, versionOrConstraint string, binPath string, force bool, dryRun bool) ([]InstalledVersion, error) {
+	return Terragrunt.UninstallVersions(ctx, versionOrConstraint, binPath, force, dryRun)
+}
+
+// UninstallVersions : remove the installed versions of the product matching a version or a constraint, see UninstallVersions
+func (product *Product) UninstallVersions(ctx context.Context, versionOrConstraint string, binPath string, force bool, dryRun bool) ([]InstalledVersion, error) {
+
+	var constraints semver.Constraints
+	if !ValidVersionFormat(versionOrConstraint) {
+		var err error
+		if constraints, err = semver.NewConstraint(versionOrConstraint); err != nil {
+			return nil, &InvalidVersionError{Version: versionOrConstraint, Product: product.Name}
+		}
+	}
+
+	lock, err := product.lockInstallLocation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	installed, _, err := product.ListInstalledVersions(binPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []InstalledVersion
+	for _, version := range installed {
+		if constraints == nil {
+			if version.Version == versionOrConstraint {
+				matched = append(matched, version)
+			}
+			continue
+		}
+		if parsed, err := semver.NewVersion(version.Version); err == nil && constraints.Check(parsed) {
+			matched = append(matched, version)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, &NotInstalledError{Version: versionOrConstraint, Product: product.Name}
+	}
+
+	var removed []InstalledVersion
+	for _, version := range matched {
+		if version.Current && !force {
+			if constraints == nil {
+				return nil, fmt.Errorf("%s version %s is currently in use, switch to another version before uninstalling it", product.Name, version.Version)
+			}
+			fmt.Fprintf(output, "Keeping %s version %q, it is currently in use\n", product.Name, version.Version)
+			continue
+		}
+
+		if !dryRun {
+			if err := product.removeInstalledVersion(version); err != nil {
+				return removed, err
+			}
+		}
+		removed = append(removed, version)
+	}
+	return removed, nil
+}
+
+// PruneVersions : remove the installed versions that are not kept by the policy. The version in use and the
+// versions in the recent file are only removed when forced. Returns the removed versions
+func PruneVersions(ctx context.Context, binPath string, policy PrunePolicy) ([]InstalledVersion, error) {
+	return Terragrunt.PruneVersions(ctx, binPath, policy)
+}
+
+// PruneVersions : remove the installed versions of the product that are not kept by the policy, see PruneVersions
+func (product *Product) PruneVersions(ctx context.Context, binPath string, policy PrunePolicy) ([]InstalledVersion, error) {
+
+	if policy.empty() {
+		return nil, errors.New("no retention policy provided, refusing to remove every installed version")
+	}
+
+	lock, err := product.lockInstallLocation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	installed, _, err := product.ListInstalledVersions(binPath)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := map[string]bool{}
+
+	/* keep the most recently used versions */
+	if policy.KeepRecent > 0 {
+		byUsage := append([]InstalledVersion{}, installed...)
+		sort.SliceStable(byUsage, func(i, j int) bool { return byUsage[i].LastUsed.After(byUsage[j].LastUsed) })
+		for i := 0; i < policy.KeepRecent && i < len(byUsage); i++ {
+			keep[byUsage[i].Version] = true
+		}
+	}
+
+	/* installed versions are sorted from newest to oldest, the first one of each minor version is its latest patch */
+	if policy.KeepLatestPatch {
+		minors := map[string]bool{}
+		for _, version := range installed {
+			parsed, err := semver.NewVersion(version.Version)
+			if err != nil {
+				continue
+			}
+			segments := parsed.Segments()
+			minor := fmt.Sprintf("%d.%d", segments[0], segments[1])
+			if !minors[minor] {
+				minors[minor] = true
+				keep[version.Version] = true
+			}
+		}
+	}
+
+	var removed []InstalledVersion
+	for _, version := range installed {
+		if keep[version.Version] {
+			continue
+		}
+		if policy.UnusedFor > 0 && time.Since(version.LastUsed) < policy.UnusedFor {
+			continue
+		}
+		if (version.Current || version.Recent) && !policy.Force {
+			fmt.Fprintf(output, "Keeping %s version %q, it is in use or was recently used\n", product.Name, version.Version)
+			continue
+		}
+
+		if !policy.DryRun {
+			if err := product.removeInstalledVersion(version); err != nil {
+				return removed, err
+			}
+		}
+		removed = append(removed, version)
+	}
+	return removed, nil
+}
+
+// removeInstalledVersion : remove the binary of a version and forget its checksum, recent entry and usage.
+// The caller holds the lock on the install location
+func (product *Product) removeInstalledVersion(version InstalledVersion) error {
+
+	if err := RemoveFiles(version.Path); err != nil {
+		return err
+	}
+	fmt.Fprintf(output, "Uninstalled %s version %q \n", product.Name, version.Version)
+
+	installLocation := filepath.Dir(version.Path)
+	if err := RemoveChecksum(installLocation, filepath.Base(version.Path)); err != nil {
+		fmt.Fprintf(output, "[Warning] : Unable to remove recorded checksum: %s\n", err)
+	}
+
+	if err := product.removeRecent(version.Version); err != nil {
+		fmt.Fprintf(output, "[Warning] : Unable to update recent versions: %s\n", err)
+	}
+
+	if err := removeUsage(installLocation, version.Version); err != nil {
+		fmt.Fprintf(output, "[Warning] : Unable to update version usage: %s\n", err)
+	}
+
+	if err := os.RemoveAll(filepath.Join(installLocation, linkDirs, version.Version)); err != nil {
+		fmt.Fprintf(output, "[Warning] : Unable to remove the link directory: %s\n", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package lib_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+// TestUninstallVersion : an installed version is removed with its recorded checksum and recent entry,
+// the version in use is kept
+func TestUninstallVersion(t *testing.T) {
+
+	installLocation := newTestInstallLocation(t, "0.38.0", "0.26.7")
+	binPath := filepath.Join(t.TempDir(), "terragrunt")
+	if err := os.Symlink(filepath.Join(installLocation, getInstallFile("terragrunt_0.38.0")), binPath); err != nil {
+		t.Fatal(err)
+	}
+	for _, version := range []string{"0.38.0", "0.26.7"} {
+		if err := lib.RecordChecksum(installLocation, getInstallFile("terragrunt_"+version), testBinaryDigest); err != nil {
+			t.Fatal(err)
+		}
+		if err := lib.AddRecent(version); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := lib.UninstallVersion(context.Background(), "0.38.0", binPath); err == nil {
+		t.Error("The version in use should not be uninstalled [unexpected]")
+	}
+
+	if err := lib.UninstallVersion(context.Background(), "0.26.7", binPath); err != nil {
+		t.Fatalf("Unable to uninstall version %v [unexpected]", err)
+	}
+
+	installed, _ := lib.GetInstalledVersions()
+	if !reflect.DeepEqual(installed, []string{"0.38.0"}) {
+		t.Errorf("Expected only 0.38.0 to be installed, got %v [unexpected]", installed)
+	}
+
+	checksums, _ := lib.ReadChecksums(filepath.Join(installLocation, "SHA256SUMS"))
+	if _, recorded := checksums[getInstallFile("terragrunt_0.26.7")]; recorded {
+		t.Error("Checksum of uninstalled version should be removed [unexpected]")
+	}
+
+	recent, _ := lib.ReadLines(filepath.Join(installLocation, "RECENT"))
+	if !reflect.DeepEqual(recent, []string{"0.38.0"}) {
+		t.Errorf("Expected only 0.38.0 in recent versions, got %v [unexpected]", recent)
+	}
+
+	var notInstalled *lib.NotInstalledError
+	if err := lib.UninstallVersion(context.Background(), "0.26.7", binPath); errors.As(err, &notInstalled) {
+		t.Logf("Returned not installed error %v [expected]", err)
+	} else {
+		t.Errorf("Expected a not installed error, got %v [unexpected]", err)
+	}
+}
+
+// TestUninstallVersionsConstraint : every installed version matching a constraint is removed, a dry run removes nothing
+func TestUninstallVersionsConstraint(t *testing.T) {
+
+	newTestInstallLocation(t, "0.38.0", "0.37.2", "0.37.1", "0.26.7")
+	binPath := filepath.Join(t.TempDir(), "terragrunt")
+
+	removed, err := lib.UninstallVersions(context.Background(), "~> 0.37.0", binPath, false, true)
+	if err != nil || len(removed) != 2 {
+		t.Errorf("Expected dry run to match 2 versions, got %v %v [unexpected]", removed, err)
+	}
+	if installed, _ := lib.GetInstalledVersions(); len(installed) != 4 {
+		t.Errorf("Dry run should not remove versions, got %v [unexpected]", installed)
+	}
+
+	if _, err := lib.UninstallVersions(context.Background(), "~> 0.37.0", binPath, false, false); err != nil {
+		t.Fatalf("Unable to uninstall versions %v [unexpected]", err)
+	}
+
+	installed, _ := lib.GetInstalledVersions()
+	expected := []string{"0.38.0", "0.26.7"}
+	if reflect.DeepEqual(installed, expected) {
+		t.Logf("Remaining versions %v [expected]", installed)
+	} else {
+		t.Errorf("Expected remaining versions %v, got %v [unexpected]", expected, installed)
+	}
+}
+
+// TestPruneVersions : pruning keeps the versions selected by the policy, the version in use and the recent versions
+func TestPruneVersions(t *testing.T) {
+
+	prune := func(t *testing.T, policy lib.PrunePolicy) []string {
+		installLocation := newTestInstallLocation(t, "0.38.4", "0.38.0", "0.37.2", "0.37.1", "0.26.7")
+		binPath := filepath.Join(t.TempDir(), "terragrunt")
+		if err := os.Symlink(filepath.Join(installLocation, getInstallFile("terragrunt_0.26.7")), binPath); err != nil {
+			t.Fatal(err)
+		}
+
+		/* 0.37.1 was used yesterday, the other versions were installed and used 30 days ago */
+		old := time.Now().AddDate(0, 0, -30)
+		lastUsed := []string{"0.37.1 " + time.Now().AddDate(0, 0, -1).UTC().Format(time.RFC3339)}
+		for _, version := range []string{"0.38.4", "0.38.0", "0.37.2", "0.37.1", "0.26.7"} {
+			if err := os.Chtimes(filepath.Join(installLocation, getInstallFile("terragrunt_"+version)), old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := lib.WriteLines(lastUsed, filepath.Join(installLocation, "LAST_USED")); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := lib.PruneVersions(context.Background(), binPath, policy); err != nil {
+			t.Fatalf("Unable to prune versions %v [unexpected]", err)
+		}
+		installed, _ := lib.GetInstalledVersions()
+		return installed
+	}
+
+	tests := []struct {
+		name     string
+		policy   lib.PrunePolicy
+		expected []string
+	}{
+		{"Keep most recently used", lib.PrunePolicy{KeepRecent: 1}, []string{"0.37.1", "0.26.7"}},
+		{"Keep latest patch per minor", lib.PrunePolicy{KeepLatestPatch: true}, []string{"0.38.4", "0.37.2", "0.26.7"}},
+		{"Remove unused versions", lib.PrunePolicy{UnusedFor: 7 * 24 * time.Hour}, []string{"0.37.1", "0.26.7"}},
+		{"Force removes the version in use", lib.PrunePolicy{KeepRecent: 1, Force: true}, []string{"0.37.1"}},
+		{"Dry run removes nothing", lib.PrunePolicy{KeepRecent: 1, DryRun: true}, []string{"0.38.4", "0.38.0", "0.37.2", "0.37.1", "0.26.7"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if installed := prune(t, test.policy); reflect.DeepEqual(installed, test.expected) {
+				t.Logf("Remaining versions %v [expected]", installed)
+			} else {
+				t.Errorf("Expected remaining versions %v, got %v [unexpected]", test.expected, installed)
+			}
+		})
+	}
+
+	t.Run("Empty policy is refused", func(t *testing.T) {
+		newTestInstallLocation(t, "0.38.4")
+		if _, err := lib.PruneVersions(context.Background(), "", lib.PrunePolicy{}); err == nil {
+			t.Error("Pruning without a policy should fail [unexpected]")
+		}
+	})
+}
+
+// TestPruneRunVersions : versions run through a link directory or a shim count as used, prune keeps them
+func TestPruneRunVersions(t *testing.T) {
+
+	installLocation := newTestInstallLocation(t, "0.38.0", "0.37.2", "0.26.7")
+	old := time.Now().AddDate(0, 0, -30)
+	for _, version := range []string{"0.38.0", "0.37.2", "0.26.7"} {
+		if err := os.Chtimes(filepath.Join(installLocation, getInstallFile("terragrunt_"+version)), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := lib.Terragrunt.LinkDir("0.37.2"); err != nil {
+		t.Fatalf("Unable to link version %v [unexpected]", err)
+	}
+	if err := lib.Terragrunt.RecordUsage("0.26.7"); err != nil {
+		t.Fatalf("Unable to record usage %v [unexpected]", err)
+	}
+
+	if _, err := lib.PruneVersions(context.Background(), "", lib.PrunePolicy{UnusedFor: 7 * 24 * time.Hour, Force: true}); err != nil {
+		t.Fatalf("Unable to prune versions %v [unexpected]", err)
+	}
+
+	installed, _ := lib.GetInstalledVersions()
+	expected := []string{"0.37.2", "0.26.7"}
+	if reflect.DeepEqual(installed, expected) {
+		t.Logf("Remaining versions %v [expected]", installed)
+	} else {
+		t.Errorf("Expected remaining versions %v, got %v [unexpected]", expected, installed)
+	}
+}
@@ -0,0 +1,110 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	usageFile     = "LAST_USED" //last time each installed version was used
+	usageInterval = time.Hour   //a version run again within the interval is not recorded again, shims run on every command
+)
+
+// readUsage : read the last time each version was used. A missing file gives an empty map
+func readUsage(installLocation string) (map[string]time.Time, error) {
+	path := filepath.Join(installLocation, usageFile)
+
+	usage := map[string]time.Time{}
+	if !CheckFileExist(path) {
+		return usage, nil
+	}
+
+	lines, err := ReadLines(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !ValidVersionFormat(fields[0]) {
+			continue
+		}
+		usedAt, err := time.Parse(time.RFC3339, fields[1])
+		if err != nil {
+			continue
+		}
+		usage[fields[0]] = usedAt
+	}
+	return usage, nil
+}
+
+// writeUsage : write the last time each version was used, sorted by version
+func writeUsage(installLocation string, usage map[string]time.Time) error {
+	versions := make([]string, 0, len(usage))
+	for version := range usage {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+
+	lines := make([]string, 0, len(versions))
+	for _, version := range versions {
+		lines = append(lines, fmt.Sprintf("%s %s", version, usage[version].UTC().Format(time.RFC3339)))
+	}
+	return WriteLines(lines, filepath.Join(installLocation, usageFile))
+}
+
+// recordUsage : record that a version was used now, the caller holds the lock on the install location
+func recordUsage(installLocation string, tgVersion string) error {
+	usage, err := readUsage(installLocation)
+	if err != nil {
+		return err
+	}
+	usage[tgVersion] = time.Now()
+	return writeUsage(installLocation, usage)
+}
+
+// RecordUsage : record that a version was run without switching to it, through a shim, tgswitch exec or the shell hook,
+// so prune keeps it like the versions switched to. Nothing is recorded while another process holds the lock on the
+// install location, running the version does not wait for it
+func (product *Product) RecordUsage(version string) error {
+	installLocation, err := product.InstallLocation()
+	if err != nil {
+		return err
+	}
+	if usage, err := readUsage(installLocation); err == nil && time.Since(usage[version]) < usageInterval {
+		return nil
+	}
+
+	lock, err := AcquireLock(context.Background(), installLocation, 0)
+	var timeoutErr *LockTimeoutError
+	if errors.As(err, &timeoutErr) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	if err := product.addRecent(version); err != nil {
+		return err
+	}
+	return recordUsage(installLocation, version)
+}
+
+// removeUsage : forget when an uninstalled version was used, the caller holds the lock on the install location
+func removeUsage(installLocation string, tgVersion string) error {
+	usage, err := readUsage(installLocation)
+	if err != nil {
+		return err
+	}
+	if _, used := usage[tgVersion]; !used {
+		return nil
+	}
+	delete(usage, tgVersion)
+	return writeUsage(installLocation, usage)
+}
@@ -0,0 +1,176 @@
+package lib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	versionListCacheFile  = ".version-lists.json" //version lists downloaded per url
+	DefaultVersionListTTL = time.Hour             //how long a downloaded version list is used without checking for new versions
+)
+
+var (
+	versionListTTL     = DefaultVersionListTTL
+	refreshVersionList = false
+)
+
+// versionListClient : client downloading the version lists
+var versionListClient = &http.Client{
+	Timeout: time.Second * 10, // Maximum of 10 secs [decresing this seem to fail]
+}
+
+// cachedVersionList : the releases of a version list and the validators to check it for changes
+type cachedVersionList struct {
+	Releases     []Release `json:"releases"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Fetched      time.Time `json:"fetched"` // last time the list was downloaded or found unchanged
+}
+
+// SetVersionListTTL : set how long a downloaded version list is used before checking it for new versions.
+// With 0 the version list is checked every time
+func SetVersionListTTL(ttl time.Duration) {
+	versionListTTL = ttl
+}
+
+// SetRefreshVersionList : with refresh the version lists are downloaded again, ignoring the cached lists
+func SetRefreshVersionList(refresh bool) {
+	refreshVersionList = refresh
+}
+
+// remoteReleases : get the releases of the version list. The list is cached in the install location: within the
+// TTL the cached list is used as is, after the TTL it is only downloaded again when it changed, and when the version
+// list cannot be downloaded the cached list is used whatever its age
+func (product *Product) remoteReleases(listURL string) ([]Release, error) {
+	cachePath := ""
+	if installLocation, err := product.InstallLocation(); err == nil {
+		cachePath = filepath.Join(installLocation, versionListCacheFile)
+	}
+	cache := readVersionListCache(cachePath)
+
+	cached, found := cache[listURL]
+	found = found && cached.Releases != nil //lists cached by older versions of tgswitch only had the versions
+	if found && !refreshVersionList && time.Since(cached.Fetched) < versionListTTL {
+		return cached.Releases, nil
+	}
+
+	var validators *cachedVersionList
+	if found && !refreshVersionList {
+		validators = &cached
+	}
+	list, err := product.fetchVersionList(listURL, validators)
+	if err != nil {
+		var downloadErr *DownloadError
+		if found && !refreshVersionList && errors.As(err, &downloadErr) {
+			fmt.Fprintf(output, "[Warning] : %v, using the version list downloaded on %s\n", err, cached.Fetched.Local().Format(time.RFC1123))
+			return cached.Releases, nil
+		}
+		return nil, err
+	}
+
+	cache[listURL] = *list
+	if cachePath != "" {
+		writeVersionListCache(cachePath, cache) //without the cache the list is downloaded again next time
+	}
+	return list.Releases, nil
+}
+
+// fetchVersionList : download and parse the version list, following the next page links of a paginated list. With
+// the validators of a cached list, the cached releases are returned when the server reports the list did not change
+func (product *Product) fetchVersionList(listURL string, cached *cachedVersionList) (*cachedVersionList, error) {
+	list := &cachedVersionList{Releases: []Release{}, Fetched: time.Now()}
+	pageURL := listURL
+	for page := 0; pageURL != ""; page++ {
+		if page == maxVersionListPages {
+			return nil, &DownloadError{URL: listURL, Err: fmt.Errorf("more than %d pages", maxVersionListPages)}
+		}
+
+		req, err := http.NewRequest(http.MethodGet, pageURL, nil)
+		if err != nil {
+			return nil, &DownloadError{URL: pageURL, Err: err}
+		}
+		req.Header.Set("User-Agent", "github-appinstaller")
+		setGitHubHeaders(req)
+		/* the validators are those of the first page, the list did not change when the first page did not */
+		if page == 0 && cached != nil && cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if page == 0 && cached != nil && cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+
+		res, err := versionListClient.Do(req)
+		if err != nil {
+			return nil, &DownloadError{URL: pageURL, Err: err}
+		}
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, &DownloadError{URL: pageURL, Err: err}
+		}
+
+		if page == 0 && res.StatusCode == http.StatusNotModified && cached != nil {
+			unchanged := *cached
+			unchanged.Fetched = time.Now()
+			return &unchanged, nil
+		}
+		if err := rateLimitError(res); err != nil {
+			return nil, &DownloadError{URL: pageURL, Err: err}
+		}
+		if res.StatusCode != http.StatusOK {
+			return nil, &DownloadError{URL: pageURL, Err: fmt.Errorf("unexpected response %s", res.Status)}
+		}
+		warnRateLimit(res)
+
+		releases, err := product.parseVersionList(body)
+		if err != nil {
+			return nil, &DownloadError{URL: pageURL, Err: fmt.Errorf("unable to parse version list: %w", err)}
+		}
+		list.Releases = append(list.Releases, releases...)
+		if page == 0 {
+			list.ETag = res.Header.Get("ETag")
+			list.LastModified = res.Header.Get("Last-Modified")
+		}
+		pageURL = nextPageURL(res)
+	}
+	return list, nil
+}
+
+// readVersionListCache : read the cached version lists, a missing or corrupted cache is empty
+func readVersionListCache(path string) map[string]cachedVersionList {
+	cache := map[string]cachedVersionList{}
+	if path == "" {
+		return cache
+	}
+	if content, err := ioutil.ReadFile(path); err == nil {
+		if json.Unmarshal(content, &cache) != nil {
+			return map[string]cachedVersionList{}
+		}
+	}
+	return cache
+}
+
+// writeVersionListCache : write the cached version lists, replacing the previous cache at once
+func writeVersionListCache(path string, cache map[string]cachedVersionList) error {
+	content, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+
+	tmpFile := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	if err := ioutil.WriteFile(tmpFile, content, 0644); err != nil {
+		return pathError(tmpFile, err)
+	}
+	if err := os.Rename(tmpFile, path); err != nil {
+		os.Remove(tmpFile)
+		return pathError(path, err)
+	}
+	return nil
+}
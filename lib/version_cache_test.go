@@ -0,0 +1,85 @@
+package lib_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+// TestVersionListCache : the version list is downloaded once within the TTL, revalidated after it, downloaded
+// again with refresh and read from the cache when the server is unavailable
+func TestVersionListCache(t *testing.T) {
+
+	newTestInstallLocation(t)
+	t.Cleanup(func() {
+		lib.SetVersionListTTL(lib.DefaultVersionListTTL)
+		lib.SetRefreshVersionList(false)
+	})
+
+	const etag = `"v1"`
+	var requests, conditional int
+	available := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if !available {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(`{"Versions": ["0.38.0", "0.37.1", "0.38.1-rc1"]}`))
+	}))
+	defer server.Close()
+
+	expected := []string{"0.38.0", "0.37.1"}
+	list := func() []string {
+		versions, err := lib.GetTGList(server.URL, false)
+		if err != nil {
+			t.Fatalf("Unable to get the version list %v [unexpected]", err)
+		}
+		return versions
+	}
+
+	if versions := list(); !reflect.DeepEqual(versions, expected) || requests != 1 {
+		t.Errorf("Expected %v from 1 request, got %v from %d [unexpected]", expected, versions, requests)
+	}
+	if versions := list(); !reflect.DeepEqual(versions, expected) || requests != 1 {
+		t.Errorf("Expected the cached list within the TTL, got %v from %d requests [unexpected]", versions, requests)
+	} else {
+		t.Log("Cached list used within the TTL [expected]")
+	}
+
+	lib.SetVersionListTTL(0)
+	if versions := list(); !reflect.DeepEqual(versions, expected) || conditional != 1 {
+		t.Errorf("Expected the list to be revalidated, got %v after %d conditional requests [unexpected]", versions, conditional)
+	} else {
+		t.Log("Unchanged list revalidated with If-None-Match [expected]")
+	}
+
+	lib.SetRefreshVersionList(true)
+	if versions := list(); !reflect.DeepEqual(versions, expected) || requests != 3 || conditional != 1 {
+		t.Errorf("Expected the list to be downloaded again, got %v from %d requests [unexpected]", versions, requests)
+	}
+
+	available = false
+	lib.SetRefreshVersionList(false)
+	if versions := list(); !reflect.DeepEqual(versions, expected) {
+		t.Errorf("Expected the cached list when the server is unavailable, got %v [unexpected]", versions)
+	} else {
+		t.Log("Cached list used when the server is unavailable [expected]")
+	}
+
+	lib.SetRefreshVersionList(true)
+	var downloadErr *lib.DownloadError
+	if _, err := lib.GetTGList(server.URL, false); !errors.As(err, &downloadErr) {
+		t.Errorf("Expected a download error with refresh, got %v [unexpected]", err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	lib "github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+// lockResult : the versions pinned by tgswitch lock
+type lockResult struct {
+	File     string                        `json:"file"`
+	Products map[string]*lib.LockedVersion `json:"products"`
+}
+
+// runLock : pin the version resolved from the version sources, and with --terraform the terraform or tofu version,
+// in the lock file next to the constraint. Locked versions that still satisfy the constraint are kept unless --upgrade
+func runLock(ctx context.Context, args []string, opts *commandOptions) error {
+	products := []*commandOptions{opts}
+	if opts.terraform.enabled && opts.product == lib.Terragrunt {
+		products = append(products, terraformCommandOptions(opts))
+	}
+
+	var lock *lib.LockFile
+	for i, productOpts := range products {
+		product := productOpts.product
+		resolution, err := newResolver(nil, productOpts).Resolve(opts.dir)
+		if err != nil {
+			return err
+		}
+		if resolution.Version == "" {
+			if i == 0 {
+				versionFile := product.VersionFiles[len(product.VersionFiles)-1]
+				return fmt.Errorf("no %s version found for %s, add a %s file or a %s to lock", product.Name, opts.dir, versionFile, product.ConstraintAttribute)
+			}
+			fmt.Fprintf(lib.Output(), "No %s version required for %s, %s is not locked\n", product.Name, opts.dir, product.Name)
+			continue
+		}
+
+		/* every product is locked in the file next to the terragrunt constraint */
+		if lock == nil {
+			if lock, err = lib.ReadLockFile(lib.LockFilePath(resolution, opts.dir, opts.stopAt)); err != nil {
+				return err
+			}
+		}
+
+		locked, found := lock.Products[product.Name]
+		if found && !opts.upgrade && locked.Constraint == resolution.Version && locked.Satisfies(resolution.Version) {
+			fmt.Fprintf(lib.Output(), "Keeping %s version %s locked for %q\n", product.Name, locked.Version, resolution.Version)
+			continue
+		}
+
+		version := ""
+		if found && !opts.upgrade && locked.Satisfies(resolution.Version) {
+			version = locked.Version //the constraint changed, but still allows the locked version
+		} else if version, err = resolveVersion(resolution.Version, productOpts); err != nil {
+			return err
+		}
+		if lock.Products[product.Name], err = product.LockVersion(ctx, version, resolution.Version, productOpts.mirrorURL); err != nil {
+			return err
+		}
+		fmt.Fprintf(lib.Output(), "Locked %s version %s for %q from %s\n", product.Name, version, resolution.Version, resolutionLocation(resolution))
+	}
+
+	if err := lock.Write(); err != nil {
+		return err
+	}
+	if jsonOutput() {
+		return printJSON(lockResult{File: lock.Path, Products: lock.Products})
+	}
+	fmt.Printf("Wrote %s\n", lock.Path)
+	return nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	lib "github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+const testDigest = "0b1b1d1d0b1b1d1d0b1b1d1d0b1b1d1d0b1b1d1d0b1b1d1d0b1b1d1d0b1b1d1d"
+
+// newTestLockMirror : serve the version list and the checksum file of every version like the terragrunt releases,
+// the listed versions can be changed between runs
+func newTestLockMirror(t *testing.T, versions *[]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/releases" {
+			json.NewEncoder(w).Encode(lib.ListVersion{Versions: *versions})
+			return
+		}
+		version := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v"), "/SHA256SUMS")
+		if !strings.HasSuffix(r.URL.Path, "/SHA256SUMS") || !lib.VersionExist(version, *versions) {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(testDigest + "  terragrunt_" + runtime.GOOS + "_" + runtime.GOARCH + "\n"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestProject : a git repository with a live directory, the directory the versions are locked for
+func newTestProject(t *testing.T) (string, string) {
+	project := t.TempDir()
+	dir := filepath.Join(project, "live")
+	for _, path := range []string{filepath.Join(project, ".git"), dir} {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return project, dir
+}
+
+// readLocked : the version locked for terragrunt and its constraint
+func readLocked(t *testing.T, lockPath string) (string, string) {
+	lock, err := lib.ReadLockFile(lockPath)
+	if err != nil {
+		t.Fatalf("Unable to read the lock file %v [unexpected]", err)
+	}
+	locked, found := lock.Products["terragrunt"]
+	if !found {
+		t.Fatalf("terragrunt not locked in %s [unexpected]", lockPath)
+	}
+	return locked.Version, locked.Constraint
+}
+
+// TestRunLock : the resolved version is locked next to the constraint and kept while it satisfies the constraint,
+// --upgrade resolves it again
+func TestRunLock(t *testing.T) {
+
+	t.Setenv("HOME", t.TempDir())
+	lib.SetOutput(io.Discard)
+	t.Cleanup(func() { lib.SetOutput(os.Stdout) })
+	verifier, err := lib.NewSignatureVerifier(nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lib.SetSignatureVerifier(verifier)
+	t.Cleanup(func() { lib.SetSignatureVerifier(nil) })
+	lib.SetVersionListTTL(0) //the version list changes between runs
+	t.Cleanup(func() { lib.SetVersionListTTL(lib.DefaultVersionListTTL) })
+
+	versions := []string{"0.38.4", "0.38.2", "0.37.1"}
+	mirror := newTestLockMirror(t, &versions)
+	_, dir := newTestProject(t)
+	opts := &commandOptions{product: lib.Terragrunt, dir: dir, mirrorURL: mirror.URL, versionURL: mirror.URL + "/releases"}
+
+	setConstraint := func(constraint string) {
+		content := "terragrunt_version_constraint = \"" + constraint + "\"\n"
+		if err := os.WriteFile(filepath.Join(dir, "terragrunt.hcl"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lockPath := filepath.Join(dir, lib.LockFilename)
+
+	steps := []struct {
+		name       string
+		constraint string
+		versions   []string
+		upgrade    bool
+		version    string
+	}{
+		{name: "Newest matching version is locked", constraint: "~> 0.38.0", versions: []string{"0.38.4", "0.38.2", "0.37.1"}, version: "0.38.4"},
+		{name: "Locked version is kept", constraint: "~> 0.38.0", versions: []string{"0.38.5", "0.38.4", "0.38.2", "0.37.1"}, version: "0.38.4"},
+		{name: "Locked version is kept for a changed constraint it satisfies", constraint: ">= 0.38.0, < 0.39", versions: []string{"0.38.5", "0.38.4", "0.38.2", "0.37.1"}, version: "0.38.4"},
+		{name: "Locked version is replaced when the constraint excludes it", constraint: "~> 0.37.0", versions: []string{"0.38.5", "0.38.4", "0.38.2", "0.37.1"}, version: "0.37.1"},
+		{name: "Upgrade resolves the constraint again", constraint: "~> 0.38.0", versions: []string{"0.38.5", "0.38.4", "0.38.2", "0.37.1"}, upgrade: true, version: "0.38.5"},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name,
+			func(t *testing.T) {
+				setConstraint(step.constraint)
+				versions = step.versions
+				opts.upgrade = step.upgrade
+
+				if err := runLock(context.Background(), nil, opts); err != nil {
+					t.Fatalf("Unable to lock %v [unexpected]", err)
+				}
+				version, constraint := readLocked(t, lockPath)
+				if version != step.version || constraint != step.constraint {
+					t.Errorf("Expected %s locked for %q, got %s for %q [unexpected]", step.version, step.constraint, version, constraint)
+				}
+			},
+		)
+	}
+}
+
+// TestRunLockHomeConfig : a version read from the .tgswitch.toml of the home directory is locked in the directory,
+// where tgswitch finds the lock file again
+func TestRunLockHomeConfig(t *testing.T) {
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	lib.SetOutput(io.Discard)
+	t.Cleanup(func() { lib.SetOutput(os.Stdout) })
+	verifier, err := lib.NewSignatureVerifier(nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lib.SetSignatureVerifier(verifier)
+	t.Cleanup(func() { lib.SetSignatureVerifier(nil) })
+
+	versions := []string{"0.38.2"}
+	mirror := newTestLockMirror(t, &versions)
+	configFile := filepath.Join(home, tomlFilename)
+	if err := os.WriteFile(configFile, []byte("version = \"0.38.2\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, dir := newTestProject(t)
+	opts := &commandOptions{product: lib.Terragrunt, dir: dir, mirrorURL: mirror.URL, versionURL: mirror.URL + "/releases", configVersion: "0.38.2", configFile: configFile}
+
+	if err := runLock(context.Background(), nil, opts); err != nil {
+		t.Fatalf("Unable to lock %v [unexpected]", err)
+	}
+
+	if lib.CheckFileExist(filepath.Join(home, lib.LockFilename)) {
+		t.Error("Lock file written in the home directory [unexpected]")
+	}
+	lockPath, found := lib.FindLockFile(dir, "")
+	if !found || lockPath != filepath.Join(dir, lib.LockFilename) {
+		t.Errorf("Expected the lock file in %s, found %q [unexpected]", dir, lockPath)
+	}
+	if version, _ := readLocked(t, filepath.Join(dir, lib.LockFilename)); version != "0.38.2" {
+		t.Errorf("Expected 0.38.2 locked, got %s [unexpected]", version)
+	}
+}
+
+// TestResolveSourcesLocked : the constraint read from the version sources and the version the lock file pins it to
+// are reported apart
+func TestResolveSourcesLocked(t *testing.T) {
+
+	t.Setenv("HOME", t.TempDir())
+	var output bytes.Buffer
+	lib.SetOutput(&output)
+	t.Cleanup(func() { lib.SetOutput(os.Stdout) })
+
+	_, dir := newTestProject(t)
+	versionFile := filepath.Join(dir, lib.TGVersionFilename)
+	if err := os.WriteFile(versionFile, []byte("~> 0.38.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lock := &lib.LockFile{Path: filepath.Join(dir, lib.LockFilename), Products: map[string]*lib.LockedVersion{
+		"terragrunt": {Version: "0.38.4", Constraint: "~> 0.38.0", SHA256: map[string]string{}},
+	}}
+	if err := lock.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &commandOptions{product: lib.Terragrunt, dir: dir, explain: true}
+	resolution, err := resolveSources(nil, opts)
+	if err != nil || resolution.Version != "0.38.4" || resolution.Required != "~> 0.38.0" {
+		t.Fatalf("Expected ~> 0.38.0 locked to 0.38.4, got %+v %v [unexpected]", resolution, err)
+	}
+
+	for _, expected := range []string{
+		"Selected ~> 0.38.0 from " + versionFile + ", locked to 0.38.4 in " + lock.Path + "\n",
+		"Reading required version ~> 0.38.0 from " + versionFile + "\n",
+		"Using version 0.38.4 locked in " + lock.Path + "\n",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected %q in %q [unexpected]", expected, output.String())
+		}
+	}
+}
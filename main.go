@@ -18,17 +18,17 @@ package main
  */
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"github.com/hashicorp/terraform-config-inspect/tfconfig"
-	"io/ioutil"
-	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	semver "github.com/hashicorp/go-version"
-	"github.com/hashicorp/hcl2/gohcl"
-	"github.com/hashicorp/hcl2/hclparse"
 	"github.com/manifoldco/promptui"
 	"github.com/pborman/getopt"
 	"github.com/spf13/viper"
@@ -37,21 +37,24 @@ import (
 )
 
 const (
-	defaultMirror  = "https://github.com/gruntwork-io/terragrunt/releases/download/"
-	defaultVersion = "https://warrensbox.github.io/terragunt-versions-list/index.json"
-	defaultBin     = "/usr/local/bin/terragrunt" //default bin installation dir
-	defaultLatest  = ""
-	tgvFilename    = ".terragrunt-version"
-	rcFilename     = ".tgswitchrc"
-	tomlFilename   = ".tgswitch.toml"
-	tgHclFilename  = "terragrunt.hcl"
-	versionPrefix  = "terragrunt_"
+	defaultBin    = "/usr/local/bin/terragrunt" //default bin installation dir
+	defaultLatest = ""
+	tomlFilename  = ".tgswitch.toml"
+	versionPrefix = "terragrunt_"
 )
 
 var version = "0.12.0\n"
 
 func main() {
-	dir := lib.GetCurrentDirectory()
+	/* named after a product, tgswitch is a shim running the version the working directory requires */
+	if product, isShim := shimProduct(os.Args[0]); isShim {
+		runAsShim(product, os.Args[1:])
+	}
+
+	dir, err := lib.GetCurrentDirectory()
+	if err != nil {
+		exitWithError(err)
+	}
 	custBinPath := getopt.StringLong("bin", 'b', lib.ConvertExecutableExt(defaultBin), "Custom binary path. Ex: tgswitch -b "+lib.ConvertExecutableExt("/Users/username/bin/terragrunt"))
 	listAllFlag := getopt.BoolLong("list-all", 'l', "List all versions of terragrunt - including beta and rc")
 	latestPre := getopt.StringLong("latest-pre", 'p', defaultLatest, "Latest pre-release implicit version. Ex: tgswitch --latest-pre 0.13 downloads 0.13.0-rc1 (latest)")
@@ -60,266 +63,351 @@ func main() {
 	showLatestStable := getopt.StringLong("show-latest-stable", 'S', defaultLatest, "Show latest implicit version. Ex: tgswitch --show-latest-stable 0.13 prints 0.13.7 (latest)")
 	latestFlag := getopt.BoolLong("latest", 'u', "Get latest stable version")
 	showLatestFlag := getopt.BoolLong("show-latest", 'U', "Show latest stable version")
-	mirrorURL := getopt.StringLong("mirror", 'm', defaultMirror, "Install from a remote API other than the default. Default: "+defaultMirror)
-	versionURL := getopt.StringLong("version_url", 'z', defaultVersion, "List from a remote API other than the default. Default: "+defaultVersion)
+	mirrorURL := getopt.StringLong("mirror", 'm', lib.Terragrunt.DefaultMirror, "Install from a remote API other than the default. Default: "+lib.Terragrunt.DefaultMirror)
+	versionURL := getopt.StringLong("version_url", 'z', lib.Terragrunt.DefaultVersionURL, "List from a remote API other than the default. Default: "+lib.Terragrunt.DefaultVersionURL)
+	productFlag := getopt.EnumLong("product", 0, lib.ProductNames(), "Product to install and switch: terragrunt (default), terraform or tofu. Ex: tgswitch --product tofu use 1.6.0", strings.Join(lib.ProductNames(), "|"))
 	chDirPath := getopt.StringLong("chdir", 'c', dir, "Switch to a different working directory before executing the given command. Ex: tgswitch --chdir terragrunt_project will run tgswitch in the terragrunt_project directory")
+	skipSignature := getopt.BoolLong("skip-signature", 0, "Skip signature verification of the release checksums. Not recommended")
+	outputFlag := getopt.EnumLong("output", 'o', []string{outputText, outputJSON}, "Print results as text (default) or as a JSON document on stdout, progress messages go to stderr with json", "text|json")
+	sortOrder := getopt.EnumLong("sort", 0, []string{"version", "installed", "size"}, "Sort order of tgswitch list: version (default), installed or size", "version|installed|size")
+	keepRecent := getopt.IntLong("keep", 0, 0, "tgswitch prune keeps the N most recently used versions", "N")
+	keepLatestPatch := getopt.BoolLong("keep-latest-patch", 0, "tgswitch prune keeps the latest patch release of each minor version")
+	unusedDays := getopt.IntLong("unused-days", 0, 0, "tgswitch prune only removes versions not used for N days", "N")
+	forceFlag := getopt.BoolLong("force", 0, "tgswitch uninstall and prune also remove the version in use, prune also removes the recent versions")
+	dryRunFlag := getopt.BoolLong("dry-run", 0, "tgswitch uninstall and prune only list the versions that would be removed")
+	upgradeFlag := getopt.BoolLong("upgrade", 0, "tgswitch lock resolves the constraints again instead of keeping the locked versions")
+	stopAt := getopt.StringLong("stop-at", 0, "", "Directory the search for version files in parent directories stops at. Default: the root of the git repository", "DIR")
+	terraformFlag := getopt.BoolLong("terraform", 0, "Also install and switch terraform to the version required by terraform_version_constraint, .terraform-version or required_version")
+	terraformProduct := getopt.EnumLong("terraform-product", 0, []string{lib.Terraform.Name, lib.OpenTofu.Name}, "Product managed alongside terragrunt with --terraform: terraform (default) or tofu", "terraform|tofu")
+	terraformBinPath := getopt.StringLong("terraform-bin", 0, "", "Custom terraform binary path used with --terraform. Default: "+lib.ConvertExecutableExt(lib.Terraform.DefaultBin)+", or "+lib.ConvertExecutableExt(lib.OpenTofu.DefaultBin)+" for tofu", "PATH")
+	terraformMirror := getopt.StringLong("terraform-mirror", 0, "", "Releases mirror terraform is installed from with --terraform. Default: "+lib.Terraform.DefaultMirror+", or "+lib.OpenTofu.DefaultMirror+" for tofu", "URL")
+	explainFlag := getopt.BoolLong("explain", 0, "Print how the version was resolved from the version sources. Ex: tgswitch --explain")
+	lockTimeout := getopt.DurationLong("lock-timeout", 0, lib.DefaultLockTimeout, "How long to wait for another tgswitch process to release the install directory. Ex: tgswitch --lock-timeout 30s")
+	cacheTTL := getopt.DurationLong("cache-ttl", 0, lib.DefaultVersionListTTL, "How long the downloaded version list is used before checking for new versions. Ex: tgswitch --cache-ttl 10m")
+	refreshFlag := getopt.BoolLong("refresh", 0, "Download the version list again instead of using the cached list")
+	strategyFlag := getopt.EnumLong("strategy", 0, lib.Strategies, "Version chosen among the versions matching a constraint: newest (default), prefer-installed or oldest", strings.Join(lib.Strategies, "|"))
+	offlineFlag := getopt.BoolLong("offline", 0, "Never download the version list, resolve versions and constraints against the installed versions only")
 	versionFlag := getopt.BoolLong("version", 'v', "Displays the version of tgswitch")
 	helpFlag := getopt.BoolLong("help", 'h', "Displays help message")
 	_ = versionFlag
 
+	getopt.SetParameters("[command] [version]")
 	getopt.Parse()
 	args := getopt.Args()
 
-	homedir := lib.GetHomeDirectory()
+	/* options may also follow the subcommand. Ex: tgswitch use 0.38.0 -b ~/bin/terragrunt */
+	var cmd *command
+	var passthroughArgs []string
+	if len(args) > 0 {
+		if found, isCommand := findCommand(args[0]); isCommand {
+			cmd = found
+			args, passthroughArgs = parseCommandArgs(cmd.name, args[1:])
+			if !cmd.passthrough {
+				args, passthroughArgs = append(args, passthroughArgs...), nil
+			}
+		}
+	}
+	setOutputFormat(*outputFlag)
+	if cmd != nil && cmd.evaluated && !jsonOutput() {
+		setShellOutput()
+	}
 
-	TGVersionFile := filepath.Join(*chDirPath, tgvFilename)    //settings for .terragrunt-version file in current directory (tgenv compatible)
-	RCFile := filepath.Join(*chDirPath, rcFilename)            //settings for .tgswitchrc file in current directory (backward compatible purpose)
-	TOMLConfigFile := filepath.Join(*chDirPath, tomlFilename)  //settings for .tgswitch.toml file in current directory (option to specify bin directory)
-	HomeTOMLConfigFile := filepath.Join(homedir, tomlFilename) //settings for .tgswitch.toml file in home directory (option to specify bin directory)
-	TGHACLFile := filepath.Join(*chDirPath, tgHclFilename)     //settings for terragrunt.hcl file in current directory (option to specify bin directory)
+	homedir, err := lib.GetHomeDirectory()
+	if err != nil {
+		exitWithError(err)
+	}
+
+	if err := setSignatureVerifier(nil, *skipSignature); err != nil {
+		exitWithError(err)
+	}
+	lib.SetLockTimeout(*lockTimeout)
+	lib.SetVersionListTTL(*cacheTTL)
+	lib.SetRefreshVersionList(*refreshFlag)
+	lib.SetOffline(*offlineFlag)
+	if *strategyFlag != "" {
+		lib.SetStrategy(*strategyFlag) //the enum only accepts known strategies
+	}
+
+	product, tfProduct := lib.Terragrunt, lib.Terraform
+	if *productFlag != "" {
+		product, _ = lib.GetProduct(*productFlag) //the enum only accepts known products
+	}
+	if *terraformProduct != "" {
+		tfProduct, _ = lib.GetProduct(*terraformProduct)
+	}
+
+	opts := &commandOptions{
+		product:     product,
+		binPath:     *custBinPath,
+		mirrorURL:   *mirrorURL,
+		versionURL:  *versionURL,
+		dir:         *chDirPath,
+		stopAt:      *stopAt,
+		terraform:   terraformOptions{enabled: *terraformFlag, product: tfProduct, binPath: *terraformBinPath, mirrorURL: *terraformMirror},
+		listAll:     *listAllFlag,
+		explain:     *explainFlag,
+		sort:        *sortOrder,
+		force:       *forceFlag,
+		dryRun:      *dryRunFlag,
+		upgrade:     *upgradeFlag,
+		prune:       lib.PrunePolicy{KeepRecent: *keepRecent, KeepLatestPatch: *keepLatestPatch, UnusedFor: time.Duration(*unusedDays) * 24 * time.Hour},
+		autoInstall: true,
+		execCommand: passthroughArgs,
+	}
+
+	/* the bin path and the mirrors default to the ones of the product */
+	if !getopt.IsSet("bin") {
+		opts.binPath = lib.ConvertExecutableExt(product.DefaultBin)
+	}
+	if !getopt.IsSet("mirror") {
+		opts.mirrorURL = product.DefaultMirror
+	}
+	if !getopt.IsSet("version_url") {
+		opts.versionURL = product.VersionURL(opts.mirrorURL)
+	}
+
+	/* Checks if the .tgswitch.toml file exist in the current or else the home directory
+	 * You can specify the custom binary path and the version you desire
+	 * If you provide a custom binary path with the -b option, this will override the bin value in the toml file
+	 * The version in the toml file has the lowest precedence of all version sources
+	 */
+	if configFile, found := findTOMLConfig(*chDirPath, homedir); found && !*versionFlag && !*helpFlag {
+		opts.configFile = configFile
+		if err := readTOMLConfig(opts, homedir, *skipSignature); err != nil {
+			exitWithError(err)
+		}
+	}
+	opts.terraform.setDefaults()
+
+	/* stop the install on Ctrl-C or SIGTERM, a second signal terminates tgswitch immediately */
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
 	switch {
 	case *versionFlag:
 		//if *versionFlag {
-		fmt.Printf("\nVersion: %v\n", version)
+		if jsonOutput() {
+			err = printJSON(versionResult{Version: strings.TrimSpace(version)})
+		} else {
+			fmt.Printf("\nVersion: %v\n", version)
+		}
 	case *helpFlag:
 		//} else if *helpFlag {
 		usageMessage()
-	/* Checks if the .tgswitch.toml file exist in home or current directory
-	 * This block checks to see if the tgswitch toml file is provided in the current path.
-	 * If the .tgswitch.toml file exist, it has a higher precedence than the .tgswitchrc file
-	 * You can specify the custom binary path and the version you desire
-	 * If you provide a custom binary path with the -b option, this will override the bin value in the toml file
-	 * If you provide a version on the command line, this will override the version value in the toml file
-	 */
-	case fileExists(TOMLConfigFile) || fileExists(HomeTOMLConfigFile):
-		version := ""
-		binPath := *custBinPath
-		if fileExists(TOMLConfigFile) { //read from toml from current directory
-			version, binPath = getParamsTOML(binPath, *chDirPath)
-		} else { // else read from toml from home directory
-			version, binPath = getParamsTOML(binPath, homedir)
-		}
 
-		switch {
-		/* GIVEN A TOML FILE, */
-		/* show all terragrunt version including betas and RCs*/
-		case *listAllFlag:
-			listAll := true //set list all true - all versions including beta and rc will be displayed
-			installOption(listAll, &binPath, mirrorURL, versionURL)
-		/* latest pre-release implicit version. Ex: tgswitch --latest-pre 0.13 downloads 0.13.0-rc1 (latest) */
-		case *latestPre != "":
-			preRelease := true
-			installLatestImplicitVersion(*latestPre, custBinPath, mirrorURL, versionURL, preRelease)
-		/* latest implicit version. Ex: tgswitch --latest 0.13 downloads 0.13.5 (latest) */
-		case *latestStable != "":
-			preRelease := false
-			installLatestImplicitVersion(*latestStable, custBinPath, mirrorURL, versionURL, preRelease)
-		/* latest stable version */
-		case *latestFlag:
-			installLatestVersion(custBinPath, mirrorURL, versionURL)
-		/* version provided on command line as arg */
-		case len(args) == 1:
-			installVersion(args[0], &binPath, mirrorURL, versionURL)
-		/* provide an tgswitchrc file (IN ADDITION TO A TOML FILE) */
-		case fileExists(RCFile) && len(args) == 0:
-			readingFileMsg(rcFilename)
-			tgversion := retrieveFileContents(RCFile)
-			installVersion(tgversion, &binPath, mirrorURL, versionURL)
-		/* if .terragrunt-version file found (IN ADDITION TO A TOML FILE) */
-		case fileExists(TGVersionFile) && len(args) == 0:
-			readingFileMsg(tgvFilename)
-			tgversion := retrieveFileContents(TGVersionFile)
-			installVersion(tgversion, &binPath, mirrorURL, versionURL)
-		/* if versions.tg file found (IN ADDITION TO A TOML FILE) */
-		case checkTGModuleFileExist(*chDirPath) && len(args) == 0:
-			installTGProvidedModule(*chDirPath, &binPath, mirrorURL, versionURL)
-		/* if Terragrunt Version environment variable is set */
-		case checkTGEnvExist() && len(args) == 0 && version == "":
-			tgversion := os.Getenv("TF_VERSION")
-			fmt.Printf("Terragrunt version environment variable: %s\n", tgversion)
-			installVersion(tgversion, custBinPath, mirrorURL, versionURL)
-		/* if terragrunt.hcl file found (IN ADDITION TO A TOML FILE) */
-		case fileExists(TGHACLFile) && checkVersionDefinedHCL(&TGHACLFile) && len(args) == 0:
-			installTGHclFile(&TGHACLFile, &binPath, mirrorURL, versionURL)
-		// if no arg is provided - but toml file is provided
-		case version != "":
-			installVersion(version, &binPath, mirrorURL, versionURL)
-		default:
-			listAll := false //set list all false - only official release will be displayed
-			installOption(listAll, &binPath, mirrorURL, versionURL)
-		}
+	/* subcommand provided as first arg. Ex: tgswitch install 0.38.0 */
+	case cmd != nil:
+		err = cmd.execute(ctx, args, opts)
 
 	/* show all terragrunt version including betas and RCs*/
 	case *listAllFlag:
-		installWithListAll(custBinPath, mirrorURL, versionURL)
+		err = installWithListAll(ctx, opts)
 
 	/* latest pre-release implicit version. Ex: tgswitch --latest-pre 0.13 downloads 0.13.0-rc1 (latest) */
 	case *latestPre != "":
 		preRelease := true
-		installLatestImplicitVersion(*latestPre, custBinPath, mirrorURL, versionURL, preRelease)
+		err = installLatestImplicitVersion(ctx, *latestPre, opts, preRelease)
 
 	/* show latest pre-release implicit version. Ex: tgswitch --latest-pre 0.13 downloads 0.13.0-rc1 (latest) */
 	case *showLatestPre != "":
 		preRelease := true
-		showLatestImplicitVersion(*showLatestPre, custBinPath, mirrorURL, versionURL, preRelease)
+		err = showLatestImplicitVersion(*showLatestPre, opts, preRelease)
 
 	/* latest implicit version. Ex: tgswitch --latest 0.13 downloads 0.13.5 (latest) */
 	case *latestStable != "":
 		preRelease := false
-		installLatestImplicitVersion(*latestStable, custBinPath, mirrorURL, versionURL, preRelease)
+		err = installLatestImplicitVersion(ctx, *latestStable, opts, preRelease)
 
 	/* show latest implicit stable version. Ex: tgswitch --latest 0.13 downloads 0.13.5 (latest) */
 	case *showLatestStable != "":
 		preRelease := false
-		showLatestImplicitVersion(*showLatestStable, custBinPath, mirrorURL, versionURL, preRelease)
+		err = showLatestImplicitVersion(*showLatestStable, opts, preRelease)
 
 	/* latest stable version */
 	case *latestFlag:
-		installLatestVersion(custBinPath, mirrorURL, versionURL)
+		err = installLatestVersion(ctx, opts)
 
 	/* show latest stable version */
 	case *showLatestFlag:
-		showLatestVersion(custBinPath, versionURL)
-
-	/* version provided on command line as arg */
-	case len(args) == 1:
-		installVersion(args[0], custBinPath, mirrorURL, versionURL)
-
-	/* provide an tgswitchrc file */
-	case fileExists(RCFile) && len(args) == 0:
-		readingFileMsg(rcFilename)
-		tgversion := retrieveFileContents(RCFile)
-		installVersion(tgversion, custBinPath, mirrorURL, versionURL)
-
-	/* if .terragrunt-version file found */
-	case fileExists(TGVersionFile) && len(args) == 0:
-		readingFileMsg(tgvFilename)
-		tgversion := retrieveFileContents(TGVersionFile)
-		installVersion(tgversion, custBinPath, mirrorURL, versionURL)
-
-	/* if versions.tg file found */
-	case checkTGModuleFileExist(*chDirPath) && len(args) == 0:
-		installTGProvidedModule(*chDirPath, custBinPath, mirrorURL, versionURL)
-
-	/* if terragrunt.hcl file found */
-	case fileExists(TGHACLFile) && checkVersionDefinedHCL(&TGHACLFile) && len(args) == 0:
-		installTGHclFile(&TGHACLFile, custBinPath, mirrorURL, versionURL)
-
-	/* if Terragrunt Version environment variable is set */
-	case checkTGEnvExist() && len(args) == 0:
-		tgversion := os.Getenv("TG_VERSION")
-		fmt.Printf("Terragrunt version environment variable: %s\n", tgversion)
-		installVersion(tgversion, custBinPath, mirrorURL, versionURL)
-
-	// if no arg is provided
+		err = showLatestVersion(opts)
+
+	/* version provided on command line as arg, or read from the version sources in order of precedence.
+	 * Without any version the versions are presented in a menu
+	 */
+	case len(args) <= 1:
+		err = switchToResolvedVersion(ctx, args, opts)
+
 	default:
 		listAll := false //set list all false - only official release will be displayed
-		installOption(listAll, custBinPath, mirrorURL, versionURL)
+		err = installOption(ctx, listAll, opts)
+	}
+
+	if err != nil {
+		exitWithError(err)
 	}
 }
 
 /* Helper functions */
 
-// install with all possible versions, including beta and rc
-func installWithListAll(custBinPath, mirrorURL *string, versionURL *string) {
-	listAll := true //set list all true - all versions including beta and rc will be displayed
-	installOption(listAll, custBinPath, mirrorURL, versionURL)
+// exit codes returned by tgswitch, one per kind of failure
+const (
+	exitError           = 1   // unexpected error
+	exitInvalidVersion  = 2   // the provided version or constraint is invalid
+	exitVersionNotFound = 3   // no terragrunt version matches the provided version or constraint
+	exitDownloadFailed  = 4   // the version list or the terragrunt binary could not be downloaded
+	exitPermission      = 5   // tgswitch is not allowed to write to the install or bin location
+	exitVerifyFailed    = 6   // the checksum or signature of the downloaded binary could not be verified
+	exitLockTimeout     = 7   // another tgswitch process held the lock on the install directory for too long
+	exitInterrupted     = 130 // tgswitch was interrupted by Ctrl-C or SIGTERM
+)
+
+// classifyError : map an error returned by lib to the exit code of tgswitch and the error type reported in json output
+func classifyError(err error) (int, string) {
+	var (
+		invalidVersion  *lib.InvalidVersionError
+		versionNotFound *lib.VersionNotFoundError
+		notInstalled    *lib.NotInstalledError
+		download        *lib.DownloadError
+		permission      *lib.PermissionError
+		checksum        *lib.ChecksumError
+		signature       *lib.SignatureError
+		lockTimeout     *lib.LockTimeoutError
+	)
+	switch {
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted, "interrupted"
+	case errors.As(err, &invalidVersion):
+		return exitInvalidVersion, "invalid_version"
+	case errors.As(err, &versionNotFound), errors.As(err, &notInstalled):
+		return exitVersionNotFound, "version_not_found"
+	case errors.As(err, &checksum), errors.As(err, &signature):
+		return exitVerifyFailed, "verification_failed"
+	case errors.As(err, &lockTimeout):
+		return exitLockTimeout, "lock_timeout"
+	case errors.As(err, &download):
+		return exitDownloadFailed, "download_failed"
+	case errors.As(err, &permission):
+		return exitPermission, "permission_denied"
+	default:
+		return exitError, "error"
+	}
 }
 
-// install latest stable tg version
-func installLatestVersion(custBinPath, mirrorURL *string, versionURL *string) {
-	tgversion, _ := lib.GetTGLatest(*mirrorURL)
-	lib.Install(tgversion, *custBinPath, *versionURL)
+// newErrorDetail : the error as printed in json mode
+func newErrorDetail(err error) errorDetail {
+	code, errorType := classifyError(err)
+	return errorDetail{Message: err.Error(), Type: errorType, Code: code}
 }
 
-// show install latest stable tg version
-func showLatestVersion(custBinPath, versionURL *string) {
-	tgversion, _ := lib.GetTGLatest(*versionURL)
-	fmt.Printf("%s\n", tgversion)
+// exitWithError : print the error and exit with the matching exit code
+func exitWithError(err error) {
+	os.Exit(printError(err))
 }
 
-// install latest - argument (version) must be provided
-func installLatestImplicitVersion(requestedVersion string, custBinPath, mirrorURL *string, versionURL *string, preRelease bool) {
-	_, err := semver.NewConstraint(requestedVersion)
-	if err != nil {
-		fmt.Printf("error parsing constraint: %s\n", err)
+// printError : print the error, on stderr when stdout is evaluated by the shell, and return the exit code it maps to.
+// An error already printed in the result document is not printed again
+func printError(err error) int {
+	code, _ := classifyError(err)
+	var reported *reportedError
+	switch {
+	case errors.As(err, &reported):
+	case shellOutput:
+		fmt.Fprintf(os.Stderr, "[Error] : %s\n", err)
+	case jsonOutput():
+		printJSON(errorResult{Error: newErrorDetail(err)})
+	default:
+		fmt.Printf("[Error] : %s\n", err)
 	}
-	//if lib.ValidMinorVersionFormat(requestedVersion) {
-	tgversion, err := lib.GetTGLatestImplicit(*versionURL, preRelease, requestedVersion)
-	if err == nil && tgversion != "" {
-		lib.Install(tgversion, *custBinPath, *mirrorURL)
+	return code
+}
+
+// printVersion : print a resolved version
+func printVersion(tgversion string) error {
+	if jsonOutput() {
+		return printJSON(versionResult{Version: tgversion})
 	}
-	fmt.Printf("Error parsing constraint: %s\n", err)
-	lib.PrintInvalidMinorTGVersion()
+	fmt.Printf("%s\n", tgversion)
+	return nil
 }
 
-// show latest - argument (version) must be provided
-func showLatestImplicitVersion(requestedVersion string, custBinPath, mirrorURL *string, versionURL *string, preRelease bool) {
-	if lib.ValidMinorVersionFormat(requestedVersion) {
-		tgversion, _ := lib.GetTGLatestImplicit(*versionURL, preRelease, requestedVersion)
-		if len(tgversion) > 0 {
-			fmt.Printf("%s\n", tgversion)
-		} else {
-			fmt.Println("The provided terragrunt version does not exist. Try `tgswitch -l` to see all available versions.")
-			os.Exit(1)
+// switchToVersion : install the version if needed and switch to it. In json mode the result is printed
+func switchToVersion(ctx context.Context, tgversion string, opts *commandOptions) error {
+	if err := opts.product.Install(ctx, tgversion, opts.binPath, opts.mirrorURL); err != nil {
+		return err
+	}
+
+	if jsonOutput() {
+		installFileVersionPath, err := opts.product.InstalledVersionPath(tgversion)
+		if err != nil {
+			return err
 		}
-	} else {
-		lib.PrintInvalidMinorTGVersion()
+		return printJSON(versionResult{Version: tgversion, Path: installFileVersionPath})
 	}
+	return nil
 }
 
-// install with provided version as argument
-func installVersion(arg string, custBinPath *string, mirrorURL *string, versionURL *string) {
-	if lib.ValidVersionFormat(arg) {
-		requestedVersion := arg
-
-		//check to see if the requested version has been downloaded before
-		installLocation := lib.GetInstallLocation()
-		installFileVersionPath := lib.ConvertExecutableExt(filepath.Join(installLocation, versionPrefix+requestedVersion))
-		recentDownloadFile := lib.CheckFileExist(installFileVersionPath)
-		if recentDownloadFile {
-			lib.ChangeSymlink(installFileVersionPath, *custBinPath)
-			fmt.Printf("Switched terragrunt to version %q \n", requestedVersion)
-			lib.AddRecent(requestedVersion) //add to recent file for faster lookup
-			os.Exit(0)
-		}
+// install with all possible versions, including beta and rc
+func installWithListAll(ctx context.Context, opts *commandOptions) error {
+	listAll := true //set list all true - all versions including beta and rc will be displayed
+	return installOption(ctx, listAll, opts)
+}
 
-		//if the requested version had not been downloaded before
-		listAll := true                                     //set list all true - all versions including beta and rc will be displayed
-		tglist, _ := lib.GetTGList(*versionURL, listAll)    //get list of versions
-		exist := lib.VersionExist(requestedVersion, tglist) //check if version exist before downloading it
+// install latest stable tg version
+func installLatestVersion(ctx context.Context, opts *commandOptions) error {
+	tgversion, err := opts.product.LatestVersion(opts.versionURL)
+	if err != nil {
+		return err
+	}
+	return switchToVersion(ctx, tgversion, opts)
+}
 
-		if exist {
-			lib.Install(requestedVersion, *custBinPath, *mirrorURL)
-		} else {
-			fmt.Println("The provided terragrunt version does not exist. Try `tgswitch -l` to see all available versions.")
-			os.Exit(1)
-		}
+// show install latest stable tg version
+func showLatestVersion(opts *commandOptions) error {
+	tgversion, err := opts.product.LatestVersion(opts.versionURL)
+	if err != nil {
+		return err
+	}
+	return printVersion(tgversion)
+}
 
-	} else {
-		lib.PrintInvalidTGVersion()
-		fmt.Println("Args must be a valid terragrunt version")
-		usageMessage()
-		os.Exit(1)
+// install latest - argument (version) must be provided
+func installLatestImplicitVersion(ctx context.Context, requestedVersion string, opts *commandOptions, preRelease bool) error {
+	_, err := semver.NewConstraint(requestedVersion)
+	if err != nil {
+		opts.product.PrintInvalidMinorVersion()
+		return &lib.InvalidVersionError{Version: requestedVersion, Product: opts.product.Name}
+	}
+	tgversion, err := opts.product.LatestImplicitVersion(opts.versionURL, preRelease, requestedVersion)
+	if err != nil {
+		return err
 	}
+	return switchToVersion(ctx, tgversion, opts)
 }
 
-//retrive file content of regular file
-func retrieveFileContents(file string) string {
-	fileContents, err := ioutil.ReadFile(file)
+// show latest - argument (version) must be provided
+func showLatestImplicitVersion(requestedVersion string, opts *commandOptions, preRelease bool) error {
+	if !lib.ValidMinorVersionFormat(requestedVersion) {
+		opts.product.PrintInvalidMinorVersion()
+		return &lib.InvalidVersionError{Version: requestedVersion, Product: opts.product.Name}
+	}
+	tgversion, err := opts.product.LatestImplicitVersion(opts.versionURL, preRelease, requestedVersion)
 	if err != nil {
-		fmt.Printf("Failed to read %s file. Follow the README.md instructions for setup. https://github.com/Swahjak/terragrunt-switcher/blob/master/README.md\n", file)
-		fmt.Printf("Error: %s\n", err)
-		os.Exit(1)
+		return err
 	}
-	tgversion := strings.TrimSuffix(string(fileContents), "\n")
-	return tgversion
+	return printVersion(tgversion)
 }
 
-// Print message reading file content of :
-func readingFileMsg(filename string) {
-	fmt.Printf("Reading file %s \n", filename)
+// findTOMLConfig : the .tgswitch.toml file of the directory, or else of the home directory
+func findTOMLConfig(dir string, homedir string) (string, bool) {
+	for _, configFile := range []string{filepath.Join(dir, tomlFilename), filepath.Join(homedir, tomlFilename)} {
+		if fileExists(configFile) {
+			return configFile, true
+		}
+	}
+	return "", false
 }
 
 // fileExists checks if a file exists and is not a directory before we try using it to prevent further errors.
@@ -331,45 +419,26 @@ func fileExists(filename string) bool {
 	return !info.IsDir()
 }
 
-// fileExists checks if a file exists and is not a directory before we
-// try using it to prevent further errors.
-func checkTGModuleFileExist(dir string) bool {
-
-	module, _ := tfconfig.LoadModule(dir)
-	if len(module.RequiredCore) >= 1 {
-		return true
-	}
-	return false
-}
-
-// checkTGEnvExist - checks if the TG_VERSION environment variable is set
-func checkTGEnvExist() bool {
-	tgversion := os.Getenv("TG_VERSION")
-	if tgversion != "" {
-		return true
-	}
-	return false
-}
-
 /* parses everything in the toml file, return required version and bin path */
-func getParamsTOML(binPath string, dir string) (string, string) {
-	path := lib.GetHomeDirectory()
+func getParamsTOML(binPath string, dir string) (string, string, error) {
+	path, err := lib.GetHomeDirectory()
+	if err != nil {
+		return "", "", err
+	}
 	if dir == path {
 		path = "home directory"
 	} else {
 		path = "current directory"
 	}
-	fmt.Printf("Reading configuration from %s\n", path+" for "+tomlFilename) //takes the default bin (defaultBin) if user does not specify bin path
-	configfileName := lib.GetFileName(tomlFilename)                          //get the config file
+	fmt.Fprintf(lib.Output(), "Reading configuration from %s\n", path+" for "+tomlFilename) //takes the default bin (defaultBin) if user does not specify bin path
+	configfileName := lib.GetFileName(tomlFilename)                                         //get the config file
 	viper.SetConfigType("toml")
 	viper.SetConfigName(configfileName)
 	viper.AddConfigPath(dir)
 
 	errs := viper.ReadInConfig() // Find and read the config file
 	if errs != nil {
-		fmt.Printf("Unable to read %s provided\n", tomlFilename) // Handle errors reading the config file
-		fmt.Println(errs)
-		os.Exit(1) // exit immediately if config file provided but it is unable to read it
+		return "", "", fmt.Errorf("unable to read %s provided: %w", tomlFilename, errs) // fail immediately if config file provided but it is unable to read it
 	}
 
 	bin := viper.Get("bin")                                            // read custom binary location
@@ -382,31 +451,119 @@ func getParamsTOML(binPath string, dir string) (string, string) {
 		version = ""
 	}
 
-	return version.(string), binPath
+	return version.(string), binPath, nil
+}
+
+// readTOMLConfig : read the .tgswitch.toml file from the current directory, or else from the home directory,
+// and apply its settings to the options. Options set on the command line override the file
+func readTOMLConfig(opts *commandOptions, homedir string, skipSignature bool) error {
+	dir := opts.dir
+	if !fileExists(filepath.Join(dir, tomlFilename)) {
+		dir = homedir
+	}
+
+	version, binPath, err := getParamsTOML(opts.binPath, dir)
+	if err != nil {
+		return err
+	}
+	opts.configVersion, opts.binPath = version, binPath
+
+	if err := setSignatureVerifier(viper.GetStringSlice("trusted_keys"), skipSignature); err != nil {
+		return err
+	}
+	if viper.IsSet("lock_timeout") && !getopt.IsSet("lock-timeout") { //the command line option overrides the toml file
+		lib.SetLockTimeout(viper.GetDuration("lock_timeout"))
+	}
+	if viper.IsSet("cache_ttl") && !getopt.IsSet("cache-ttl") {
+		lib.SetVersionListTTL(viper.GetDuration("cache_ttl"))
+	}
+	if viper.IsSet("strategy") && !getopt.IsSet("strategy") {
+		if err := lib.SetStrategy(viper.GetString("strategy")); err != nil {
+			return err
+		}
+	}
+	if viper.IsSet("offline") && !getopt.IsSet("offline") {
+		lib.SetOffline(viper.GetBool("offline"))
+	}
+	if viper.IsSet("stop_at") && !getopt.IsSet("stop-at") {
+		opts.stopAt = os.ExpandEnv(viper.GetString("stop_at"))
+	}
+	if viper.IsSet("auto_install") {
+		opts.autoInstall = viper.GetBool("auto_install")
+	}
+	return readTerraformTOMLConfig(&opts.terraform)
+}
+
+// readTerraformTOMLConfig : apply the terraform settings of the .tgswitch.toml file, options set on the command line override them
+func readTerraformTOMLConfig(terraform *terraformOptions) error {
+	if viper.IsSet("terraform") && !getopt.IsSet("terraform") {
+		terraform.enabled = viper.GetBool("terraform")
+	}
+	if viper.IsSet("terraform_product") && !getopt.IsSet("terraform-product") {
+		product, err := lib.GetProduct(viper.GetString("terraform_product"))
+		if err != nil {
+			return err
+		}
+		if product == lib.Terragrunt {
+			return fmt.Errorf("terraform_product must be %s or %s", lib.Terraform.Name, lib.OpenTofu.Name)
+		}
+		terraform.product = product
+	}
+	if viper.IsSet("terraform_bin") && !getopt.IsSet("terraform-bin") {
+		terraform.binPath = os.ExpandEnv(viper.GetString("terraform_bin"))
+	}
+	if viper.IsSet("terraform_mirror") && !getopt.IsSet("terraform-mirror") {
+		terraform.mirrorURL = viper.GetString("terraform_mirror")
+	}
+	terraform.configVersion = viper.GetString("terraform_version")
+	return nil
+}
+
+// setSignatureVerifier : trust the bundled keys and the provided keys when verifying release checksums
+func setSignatureVerifier(trustedKeys []string, skipSignature bool) error {
+	verifier, err := lib.NewSignatureVerifier(trustedKeys, skipSignature)
+	if err != nil {
+		return err
+	}
+	lib.SetSignatureVerifier(verifier)
+	return nil
 }
 
 func usageMessage() {
-	fmt.Print("\n\n")
+	fmt.Fprint(lib.Output(), "\n\n")
 	getopt.PrintUsage(os.Stderr)
-	fmt.Println("Supply the terragrunt version as an argument, or choose from a menu")
+	fmt.Fprint(os.Stderr, commandsUsage())
+	fmt.Fprintln(lib.Output(), "Supply the version as an argument, or choose from a menu")
 }
 
 /* installOption : displays & installs tg version */
 /* listAll = true - all versions including beta and rc will be displayed */
 /* listAll = false - only official stable release are displayed */
-func installOption(listAll bool, custBinPath, mirrorURL *string, versionURL *string) {
-	tglist, _ := lib.GetTGList(*versionURL, listAll) //get list of versions
-	recentVersions, _ := lib.GetRecentVersions()     //get recent versions from RECENT file
-	tglist = append(recentVersions, tglist...)       //append recent versions to the top of the list
-	tglist = lib.RemoveDuplicateVersions(tglist)     //remove duplicate version
+func installOption(ctx context.Context, listAll bool, opts *commandOptions) error {
+	releases, err := opts.product.ListReleases(opts.versionURL, listAll) //get list of versions
+	if err != nil {
+		return err
+	}
+	/* nobody can answer a prompt in json mode, print the choices instead */
+	if jsonOutput() {
+		return printRemoteVersions(releases, opts.product)
+	}
+
+	var tglist []string
+	for _, release := range releases {
+		tglist = append(tglist, release.Version)
+	}
+
+	recentVersions, _ := opts.product.RecentVersions() //get recent versions from RECENT file
+	tglist = append(recentVersions, tglist...)         //append recent versions to the top of the list
+	tglist = lib.RemoveDuplicateVersions(tglist)       //remove duplicate version
 
 	if len(tglist) == 0 {
-		fmt.Println("[ERROR] : List is empty")
-		os.Exit(1)
+		return errors.New("list is empty")
 	}
-	/* prompt user to select version of terragrunt */
+	/* prompt user to select version of the product */
 	prompt := promptui.Select{
-		Label: "Select Terragrunt version",
+		Label: fmt.Sprintf("Select %s version", opts.product.Name),
 		Items: tglist,
 	}
 
@@ -414,64 +571,8 @@ func installOption(listAll bool, custBinPath, mirrorURL *string, versionURL *str
 	tgversion = strings.Trim(tgversion, " *recent") //trim versions with the string " *recent" appended
 
 	if errPrompt != nil {
-		log.Printf("Prompt failed %v\n", errPrompt)
-		os.Exit(1)
-	}
-
-	lib.Install(tgversion, *custBinPath, *mirrorURL)
-	os.Exit(0)
-}
-
-// install when tf file is provided
-func installTGProvidedModule(dir string, custBinPath, mirrorURL *string, versionURL *string) {
-	fmt.Printf("Reading required version from terragrunt file\n")
-	module, _ := tfconfig.LoadModule(dir)
-	tgconstraint := module.RequiredCore[0] //we skip duplicated definitions and use only first one
-	installFromConstraint(&tgconstraint, custBinPath, mirrorURL, versionURL)
-}
-
-// install using a version constraint
-func installFromConstraint(tgconstraint *string, custBinPath, mirrorURL *string, versionURL *string) {
-
-	tgversion, err := lib.GetSemver(tgconstraint, versionURL)
-	if err == nil {
-		lib.Install(tgversion, *custBinPath, *mirrorURL)
+		return fmt.Errorf("prompt failed: %w", errPrompt)
 	}
-	fmt.Println(err)
-	fmt.Println("No version found to match constraint. Follow the README.md instructions for setup. https://github.com/Swahjak/terragrunt-switcher/blob/master/README.md")
-	os.Exit(1)
-}
-
-// Install using version constraint from terragrunt file
-func installTGHclFile(tgFile *string, custBinPath, mirrorURL *string, versionURL *string) {
-	fmt.Printf("Terragrunt file found: %s\n", *tgFile)
-	parser := hclparse.NewParser()
-	file, diags := parser.ParseHCLFile(*tgFile) //use hcl parser to parse HCL file
-	if diags.HasErrors() {
-		fmt.Println("Unable to parse HCL file")
-		os.Exit(1)
-	}
-	var version terragruntVersionConstraints
-	gohcl.DecodeBody(file.Body, nil, &version)
-	installFromConstraint(&version.TerragruntVersionConstraint, custBinPath, mirrorURL, versionURL)
-}
 
-type terragruntVersionConstraints struct {
-	TerragruntVersionConstraint string `hcl:"terragrunt_version_constraint"`
-}
-
-// check if version is defined in hcl file /* lazy-emergency fix - will improve later */
-func checkVersionDefinedHCL(tgFile *string) bool {
-	parser := hclparse.NewParser()
-	file, diags := parser.ParseHCLFile(*tgFile) //use hcl parser to parse HCL file
-	if diags.HasErrors() {
-		fmt.Println("Unable to parse HCL file")
-		os.Exit(1)
-	}
-	var version terragruntVersionConstraints
-	gohcl.DecodeBody(file.Body, nil, &version)
-	if version == (terragruntVersionConstraints{}) {
-		return false
-	}
-	return true
+	return switchToVersion(ctx, tgversion, opts)
 }
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	lib "github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// outputFormat : format of the results printed on stdout, set with --output
+var outputFormat = outputText
+
+// setOutputFormat : in json mode stdout only receives one JSON document, progress messages go to stderr
+func setOutputFormat(format string) {
+	if format != outputJSON {
+		return
+	}
+	outputFormat = outputJSON
+	lib.SetOutput(os.Stderr)
+}
+
+// shellOutput : stdout is evaluated by the shell, as with eval "$(tgswitch env)". Nothing but shell commands is
+// printed there, not even errors: they may contain paths the shell would run
+var shellOutput = false
+
+// setShellOutput : print the progress messages and the errors on stderr, stdout is evaluated by the shell
+func setShellOutput() {
+	shellOutput = true
+	lib.SetOutput(os.Stderr)
+}
+
+// jsonOutput : true when results are printed as JSON
+func jsonOutput() bool {
+	return outputFormat == outputJSON
+}
+
+// printJSON : print a result as an indented JSON document on stdout
+func printJSON(result interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false) //constraints such as ">= 0.30" are printed as is
+	return encoder.Encode(result)
+}
+
+// versionResult : a resolved, installed or current version
+type versionResult struct {
+	Version   string         `json:"version"`
+	Path      string         `json:"path,omitempty"`      // path of the installed binary
+	Terraform *versionResult `json:"terraform,omitempty"` // terraform version installed alongside with --terraform
+}
+
+// remoteVersion : a version available for download
+type remoteVersion struct {
+	Version   string `json:"version"`
+	Installed bool   `json:"installed"`
+	Published string `json:"published,omitempty"` // publication date, when the version list tells it
+}
+
+// remoteVersionsResult : the versions available for download
+type remoteVersionsResult struct {
+	Versions []remoteVersion `json:"versions"`
+}
+
+// installedVersionsResult : the versions found in the install location
+type installedVersionsResult struct {
+	Versions   []lib.InstalledVersion `json:"versions"`
+	StrayFiles []lib.StrayFile        `json:"stray_files"`
+}
+
+// removedVersionsResult : the versions removed by uninstall or prune
+type removedVersionsResult struct {
+	Removed []lib.InstalledVersion `json:"removed"`
+	DryRun  bool                   `json:"dry_run"`
+}
+
+// errorResult : the error tgswitch exits with
+type errorResult struct {
+	Error errorDetail `json:"error"`
+}
+
+// whyResult : the resolution explained by tgswitch why, with the error that stopped it in the same document
+type whyResult struct {
+	*lib.Resolution
+	Error *errorDetail `json:"error,omitempty"`
+}
+
+// reportedError : an error already printed in the result document, tgswitch exits with its code without printing it again
+type reportedError struct {
+	err error
+}
+
+func (e *reportedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *reportedError) Unwrap() error {
+	return e.err
+}
+
+type errorDetail struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    int    `json:"code"` // exit code of tgswitch
+}
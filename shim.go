@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	semver "github.com/hashicorp/go-version"
+
+	lib "github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+// autoInstallEnv : set to false to stop the shims and the shell hook from installing missing versions, overrides auto_install in .tgswitch.toml
+const autoInstallEnv = "TGSWITCH_AUTO_INSTALL"
+
+// shimResult : the shims created by tgswitch shim
+type shimResult struct {
+	Dir   string   `json:"dir"`
+	Shims []string `json:"shims"`
+}
+
+// defaultShimDir : the directory the shims are created in when no directory is provided
+func defaultShimDir(homedir string) string {
+	return filepath.Join(homedir, ".tgswitch", "shims")
+}
+
+// shimProduct : the product tgswitch runs as when its executable is named after the product, such as a terragrunt shim
+func shimProduct(executable string) (*lib.Product, bool) {
+	name := strings.TrimSuffix(filepath.Base(executable), ".exe")
+	product, err := lib.GetProduct(name)
+	return product, err == nil
+}
+
+// runAsShim : run as the shim of the product and exit with the exit code of the binary.
+// Errors of the shim are printed on stderr, stdout only receives the output of the binary
+func runAsShim(product *lib.Product, args []string) {
+	code, err := execShim(product, args)
+	if err != nil {
+		code, _ = classifyError(err)
+		fmt.Fprintf(os.Stderr, "[tgswitch] %s\n", err)
+	}
+	os.Exit(code)
+}
+
+// execShim : resolve the version of the product for the working directory with the same sources as tgswitch,
+// install it when it is missing and auto install is enabled, and replace the process with the installed binary.
+// Without any version source, the version the bin path points to is used
+func execShim(product *lib.Product, args []string) (int, error) {
+	lib.SetOutput(io.Discard) //nothing but installs is reported, the output belongs to the binary
+
+	dir, err := lib.GetCurrentDirectory()
+	if err != nil {
+		return 1, err
+	}
+	homedir, err := lib.GetHomeDirectory()
+	if err != nil {
+		return 1, err
+	}
+
+	opts := &commandOptions{
+		product:     product,
+		binPath:     lib.ConvertExecutableExt(product.DefaultBin),
+		mirrorURL:   product.DefaultMirror,
+		versionURL:  product.VersionURL(product.DefaultMirror),
+		dir:         dir,
+		terraform:   terraformOptions{product: lib.Terraform},
+		autoInstall: true,
+	}
+	if err := setSignatureVerifier(nil, false); err != nil {
+		return 1, err
+	}
+	if configFile, found := findTOMLConfig(dir, homedir); found {
+		opts.configFile = configFile
+		if err := readTOMLConfig(opts, homedir, false); err != nil {
+			return 1, err
+		}
+	}
+	if err := readAutoInstallEnv(opts); err != nil {
+		return 1, err
+	}
+
+	resolution, err := resolveLocked(nil, opts)
+	if err != nil {
+		return 1, err
+	}
+	version := resolution.Version
+	if version == "" {
+		if version, err = product.CurrentVersion(opts.binPath); err != nil {
+			return 1, err
+		}
+		if version == "" {
+			return 1, fmt.Errorf("no %s version set for %s and %s does not point to an installed version, run tgswitch use", product.Name, dir, opts.binPath)
+		}
+	}
+
+	version, path, err := installedBinary(version, opts)
+	if err != nil {
+		return 1, err
+	}
+	if err := product.RecordUsage(version); err != nil {
+		fmt.Fprintf(os.Stderr, "[tgswitch] [Warning] : Unable to record version usage: %s\n", err)
+	}
+	return lib.ExecBinary(path, args, os.Environ())
+}
+
+// readAutoInstallEnv : apply TGSWITCH_AUTO_INSTALL when it is set
+func readAutoInstallEnv(opts *commandOptions) error {
+	value, isSet := os.LookupEnv(autoInstallEnv)
+	if !isSet {
+		return nil
+	}
+	autoInstall, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", autoInstallEnv, value, err)
+	}
+	opts.autoInstall = autoInstall
+	return nil
+}
+
+// installedBinary : the installed version and binary for a version or a constraint. A constraint is resolved with
+// the strategy, the version list is only skipped by prefer-installed when an installed version matches. Missing
+// versions are only installed with auto install, their progress is reported on stderr
+func installedBinary(version string, opts *commandOptions) (string, string, error) {
+	product := opts.product
+
+	switch {
+	case lib.ValidVersionFormat(version):
+		installFileVersionPath, err := product.InstalledVersionPath(version)
+		if err != nil || lib.CheckFileExist(installFileVersionPath) {
+			return version, installFileVersionPath, err
+		}
+	case version != "latest":
+		if _, err := semver.NewConstraint(version); err != nil {
+			return "", "", &lib.InvalidVersionError{Version: version, Product: product.Name}
+		}
+		/* the strategy chooses among the matching versions like tgswitch use does, only prefer-installed uses an
+		 * installed version without checking the version list */
+		matched, err := product.ResolveVersion(version, opts.versionURL)
+		if err != nil {
+			return "", "", err
+		}
+		installFileVersionPath, err := product.InstalledVersionPath(matched)
+		if err != nil || lib.CheckFileExist(installFileVersionPath) {
+			return matched, installFileVersionPath, err
+		}
+		version = matched
+	}
+
+	if !opts.autoInstall {
+		return "", "", fmt.Errorf("%w, auto install is disabled: run tgswitch --product %s install %s", &lib.NotInstalledError{Version: version, Product: product.Name}, product.Name, version)
+	}
+
+	lib.SetOutput(os.Stderr)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	tgversion, err := resolveVersion(version, opts)
+	if err != nil {
+		return "", "", err
+	}
+	installFileVersionPath, err := product.InstallVersion(ctx, tgversion, opts.mirrorURL)
+	return tgversion, installFileVersionPath, err
+}
+
+// runShim : create shims named after the product, and with --terraform after terraform or tofu, pointing to the
+// tgswitch executable. With the shim directory at the front of PATH, every invocation runs the version the
+// working directory requires
+func runShim(ctx context.Context, args []string, opts *commandOptions) error {
+	homedir, err := lib.GetHomeDirectory()
+	if err != nil {
+		return err
+	}
+	dir := defaultShimDir(homedir)
+	if len(args) == 1 {
+		dir = args[0]
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err == nil {
+		executable, err = filepath.EvalSymlinks(executable)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to find the tgswitch executable: %w", err)
+	}
+
+	products := []*lib.Product{opts.product}
+	if opts.terraform.enabled && opts.product == lib.Terragrunt {
+		products = append(products, opts.terraform.product)
+	}
+
+	if err := lib.CreateDirIfNotExist(dir); err != nil {
+		return err
+	}
+	result := shimResult{Dir: dir}
+	for _, product := range products {
+		shimPath := filepath.Join(dir, lib.ConvertExecutableExt(product.Name))
+		if err := lib.ChangeSymlink(executable, shimPath); err != nil {
+			return err
+		}
+		result.Shims = append(result.Shims, shimPath)
+	}
+
+	if jsonOutput() {
+		return printJSON(result)
+	}
+	for _, shimPath := range result.Shims {
+		fmt.Printf("Created shim %s\n", shimPath)
+	}
+	fmt.Printf("Add %s to the front of PATH, for example: export PATH=\"%s:$PATH\"\n", dir, dir)
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	lib "github.com/Swahjak/terragrunt-switcher/lib"
+)
+
+// TestInstalledBinaryStrategy : the shims and the hook choose among the versions matching a constraint with the
+// strategy, like tgswitch use
+func TestInstalledBinaryStrategy(t *testing.T) {
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	installLocation := filepath.Join(home, ".terragrunt.versions")
+	if err := os.MkdirAll(installLocation, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, version := range []string{"0.38.0", "0.38.2"} {
+		if err := os.WriteFile(lib.ConvertExecutableExt(filepath.Join(installLocation, "terragrunt_"+version)), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Versions": ["0.38.4", "0.38.2", "0.38.0", "0.37.1"]}`))
+	}))
+	defer server.Close()
+
+	opts := &commandOptions{product: lib.Terragrunt, versionURL: server.URL}
+	defer lib.SetStrategy(lib.StrategyNewest)
+
+	tests := []struct {
+		strategy string
+		version  string
+	}{
+		{strategy: lib.StrategyPreferInstalled, version: "0.38.2"},
+		{strategy: lib.StrategyOldest, version: "0.38.0"},
+		{strategy: lib.StrategyNewest, version: "0.38.4"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.strategy,
+			func(t *testing.T) {
+				lib.SetStrategy(test.strategy)
+
+				version, _, err := installedBinary("~> 0.38.0", opts)
+				var notInstalled *lib.NotInstalledError
+				switch {
+				case errors.As(err, &notInstalled) && notInstalled.Version == test.version:
+					t.Logf("Missing version %s not installed without auto install [expected]", test.version)
+				case err != nil:
+					t.Errorf("Unable to resolve the constraint %v [unexpected]", err)
+				case version != test.version:
+					t.Errorf("Expected version %s, got %s [unexpected]", test.version, version)
+				}
+			},
+		)
+	}
+}